@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// BatchCache represents a Cache able to handle many items at once
+// The errors are returned by key, and are nil when every item succeeded
+type BatchCache[K comparable, V any] interface {
+	Cache[K, V]
+	GetMany(context.Context, []K) (map[K]V, map[K]error)
+	SetMany(context.Context, map[K]V, time.Duration) map[K]error
+	DeleteMany(context.Context, []K) map[K]error
+}
+
+// GetMany retrieves many items from the cache, relying on it when it is a BatchCache
+// A key is either in the values or in the errors
+func GetMany[K comparable, V any](ctx context.Context, c Cache[K, V], keys []K) (map[K]V, map[K]error) {
+	if bc, ok := c.(BatchCache[K, V]); ok {
+		return bc.GetMany(ctx, keys)
+	}
+
+	vals := make(map[K]V, len(keys))
+	var errs map[K]error
+	for _, k := range keys {
+		val, err := c.Get(ctx, k)
+		if err != nil {
+			if errs == nil {
+				errs = map[K]error{}
+			}
+			errs[k] = err
+			continue
+		}
+		vals[k] = val
+	}
+	return vals, errs
+}
+
+// SetMany stores many items to the cache with the same ttl, relying on it when it is a BatchCache
+func SetMany[K comparable, V any](ctx context.Context, c Cache[K, V], items map[K]V, ttl time.Duration) map[K]error {
+	if bc, ok := c.(BatchCache[K, V]); ok {
+		return bc.SetMany(ctx, items, ttl)
+	}
+
+	var errs map[K]error
+	for k, v := range items {
+		if err := c.Set(ctx, k, v, ttl); err != nil {
+			if errs == nil {
+				errs = map[K]error{}
+			}
+			errs[k] = err
+		}
+	}
+	return errs
+}
+
+// DeleteMany removes many items from the cache, relying on it when it is a BatchCache
+func DeleteMany[K comparable, V any](ctx context.Context, c Cache[K, V], keys []K) map[K]error {
+	if bc, ok := c.(BatchCache[K, V]); ok {
+		return bc.DeleteMany(ctx, keys)
+	}
+
+	var errs map[K]error
+	for _, k := range keys {
+		if err := c.Delete(ctx, k); err != nil {
+			if errs == nil {
+				errs = map[K]error{}
+			}
+			errs[k] = err
+		}
+	}
+	return errs
+}
+
+// manyErrors returns the same error for every key
+func manyErrors[K comparable](keys []K, err error) map[K]error {
+	errs := make(map[K]error, len(keys))
+	for _, k := range keys {
+		errs[k] = err
+	}
+	return errs
+}
+
+// keysOf returns the keys of the items
+func keysOf[K comparable, V any](items map[K]V) []K {
+	keys := make([]K, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	return keys
+}
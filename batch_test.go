@@ -0,0 +1,92 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/cachetest"
+)
+
+func TestBatch(t *testing.T) {
+	caches := map[string]func(t *testing.T) Cache[string, string]{
+		"inmem": func(t *testing.T) Cache[string, string] {
+			return newInMemHelper(t)
+		},
+		"fallback": func(t *testing.T) Cache[string, string] {
+			return singleCache{Cache: newInMemHelper(t)}
+		},
+		"multi level": func(t *testing.T) Cache[string, string] {
+			return newMultiLevel(t, cachetest.NewClock(time.Now()))
+		},
+	}
+
+	for name, newCache := range caches {
+		t.Run(name, func(t *testing.T) {
+			c := newCache(t)
+
+			errs := SetMany(context.Background(), c, map[string]string{"one": "1", "two": "2"}, NoExpiration)
+			if len(errs) != 0 {
+				t.Fatalf("could not set items: %v", errs)
+			}
+
+			vals, errs := GetMany(context.Background(), c, []string{"one", "two", "three"})
+			if len(vals) != 2 || vals["one"] != "1" || vals["two"] != "2" {
+				t.Errorf("could not match values, got: %v", vals)
+			}
+			if len(errs) != 1 || !errors.Is(errs["three"], ErrNotFound) {
+				t.Errorf("could not match errors, got: %v", errs)
+			}
+
+			if errs := DeleteMany(context.Background(), c, []string{"one", "three"}); len(errs) != 0 {
+				t.Fatalf("could not delete items: %v", errs)
+			}
+
+			vals, errs = GetMany(context.Background(), c, []string{"one", "two"})
+			if len(vals) != 1 || vals["two"] != "2" {
+				t.Errorf("could not match values after delete, got: %v", vals)
+			}
+			if len(errs) != 1 || !errors.Is(errs["one"], ErrNotGet) {
+				t.Errorf("could not match errors after delete, got: %v", errs)
+			}
+		})
+	}
+
+	t.Run("multi level fetches only the misses from the levels below", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 2)
+		remote := &countingCache{BatchCache: levels[1].Cache.(BatchCache[string, string])}
+		levels[1].Cache = remote
+		multiLvl := newMultiLevelHelper(t, levels, MultiLevelClockOption[string, string](clock))
+
+		_ = levels[0].Cache.Set(context.Background(), "one", "1", NoExpiration)
+		_ = remote.Set(context.Background(), "two", "2", NoExpiration)
+
+		vals, errs := multiLvl.GetMany(context.Background(), []string{"one", "two"})
+		if len(errs) != 0 || len(vals) != 2 {
+			t.Fatalf("could not get items, got: %v %v", vals, errs)
+		}
+
+		if len(remote.keys) != 1 || remote.keys[0] != "two" {
+			t.Errorf("could not match keys asked to the last level, got: %v", remote.keys)
+		}
+	})
+}
+
+// singleCache hides the batch operations of the wrapped cache
+type singleCache struct {
+	Cache[string, string]
+}
+
+// countingCache records the keys asked by GetMany
+type countingCache struct {
+	BatchCache[string, string]
+	keys []string
+}
+
+func (c *countingCache) GetMany(ctx context.Context, keys []string) (map[string]string, map[string]error) {
+	c.keys = append(c.keys, keys...)
+	return c.BatchCache.GetMany(ctx, keys)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -66,4 +67,54 @@ func BenchmarkLogger(b *testing.B) {
 			}
 		})
 	})
+
+	b.Run("damianopetrungaro/go-cache.tinylfu_prefilled", func(b *testing.B) {
+		inmem := cache.NewInMemory[string, []byte](10*time.Second, 10_000, cache.TinyLFUOption[string, []byte]())
+		var k, v, ttl = "k", []byte("value"), time.Second
+
+		for i := 0; i < 10_000; i++ {
+			inmem.Set(context.Background(), fmt.Sprintf("%d", i), v, ttl)
+		}
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = inmem.Get(context.Background(), k)
+				_ = inmem.Set(context.Background(), k, v, ttl)
+				_ = inmem.Delete(context.Background(), k)
+			}
+		})
+	})
+
+	b.Run("damianopetrungaro/go-cache.parallel_keys", func(b *testing.B) {
+		inmem := cache.NewInMemory[string, []byte](10*time.Second, 10_000)
+		benchmarkParallelKeys(b, inmem)
+	})
+
+	b.Run("damianopetrungaro/go-cache.sharded_parallel_keys", func(b *testing.B) {
+		inmem := cache.NewShardedInMemory[string, []byte](64, 10*time.Second, 10_000)
+		benchmarkParallelKeys(b, inmem)
+	})
+}
+
+// benchmarkParallelKeys runs every goroutine on its own set of keys, as most real workloads do
+func benchmarkParallelKeys(b *testing.B, c cache.Cache[string, []byte]) {
+	var v, ttl = []byte("value"), time.Second
+	var id int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ks := make([]string, 128)
+		n := atomic.AddInt64(&id, 1)
+		for i := range ks {
+			ks[i] = fmt.Sprintf("%d-%d", n, i)
+		}
+
+		for i := 0; pb.Next(); i++ {
+			k := ks[i%len(ks)]
+			_, _ = c.Get(context.Background(), k)
+			_ = c.Set(context.Background(), k, v, ttl)
+			_ = c.Delete(context.Background(), k)
+		}
+	})
 }
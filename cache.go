@@ -9,11 +9,14 @@ import (
 
 // List of errors returned by the Cache implementations
 var (
-	ErrNotSet    = errors.New("could not set cache value")
-	ErrNotGet    = errors.New("could not get cache value")
-	ErrNotFound  = fmt.Errorf("%w: could not find cache value", ErrNotGet)
-	ErrExpired   = fmt.Errorf("%w: could not get expired cache value", ErrNotGet)
-	ErrNotDelete = errors.New("could not delete cache value")
+	ErrNotSet     = errors.New("could not set cache value")
+	ErrNotGet     = errors.New("could not get cache value")
+	ErrNotFound   = fmt.Errorf("%w: could not find cache value", ErrNotGet)
+	ErrExpired    = fmt.Errorf("%w: could not get expired cache value", ErrNotGet)
+	ErrNotLoad    = fmt.Errorf("%w: could not load cache value", ErrNotGet)
+	ErrTooLarge   = fmt.Errorf("%w: cache value exceeds the max cost", ErrNotSet)
+	ErrNotDelete  = errors.New("could not delete cache value")
+	ErrNotPublish = errors.New("could not publish cache invalidation")
 )
 
 const (
@@ -27,3 +30,9 @@ type Cache[K comparable, V any] interface {
 	Set(context.Context, K, V, time.Duration) error
 	Delete(context.Context, K) error
 }
+
+// TTLer represents a cache able to tell the ttl left to an item, NoExpiration meaning that it never expires
+// The errors returned are the same as the ones returned by Get
+type TTLer[K comparable] interface {
+	TTL(context.Context, K) (time.Duration, error)
+}
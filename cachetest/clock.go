@@ -0,0 +1,141 @@
+// Package cachetest provides utilities for testing code relying on the cache package
+package cachetest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/damianopetrungaro/go-cache"
+)
+
+var _ cache.Clock = &Clock{}
+
+// Clock is a cache.Clock whose time moves only when Advance is called
+// Tickers deliver at most one pending tick as the time package does,
+// and timers run their function synchronously in Advance
+// It is concurrent safe
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*ticker
+	timers  []*timer
+}
+
+// NewClock returns a Clock set at the given time
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the clock
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTicker returns a cache.Ticker ticking every d as the clock advances
+func (c *Clock) NewTicker(d time.Duration) cache.Ticker {
+	if d <= 0 {
+		panic("cachetest: non-positive interval for NewTicker")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &ticker{clock: c, ch: make(chan time.Time, 1), period: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// AfterFunc returns a cache.Timer calling f once the clock advances by d
+func (c *Clock) AfterFunc(d time.Duration, f func()) cache.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, delivering the due ticks and running the due timers
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+
+	for _, t := range c.tickers {
+		if t.next.After(now) {
+			continue
+		}
+
+		select {
+		case t.ch <- now:
+		default:
+		}
+
+		for !t.next.After(now) {
+			t.next = t.next.Add(t.period)
+		}
+	}
+
+	var due, pending []*timer
+	for _, t := range c.timers {
+		switch t.at.After(now) {
+		case true:
+			pending = append(pending, t)
+		default:
+			due = append(due, t)
+		}
+	}
+	c.timers = pending
+	c.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	for _, t := range due {
+		t.f()
+	}
+}
+
+type ticker struct {
+	clock  *Clock
+	ch     chan time.Time
+	period time.Duration
+	next   time.Time
+}
+
+func (t *ticker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *ticker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, tt := range t.clock.tickers {
+		if tt == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
+
+type timer struct {
+	clock *Clock
+	at    time.Time
+	f     func()
+}
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, tt := range t.clock.timers {
+		if tt == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package cachetest_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/damianopetrungaro/go-cache/cachetest"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2022, 6, 9, 0, 0, 0, 0, time.UTC)
+
+	t.Run("advance time", func(t *testing.T) {
+		c := NewClock(start)
+		c.Advance(time.Minute)
+
+		if got, want := c.Now(), start.Add(time.Minute); !got.Equal(want) {
+			t.Errorf("could not match time, got: %s. want: %s", got, want)
+		}
+	})
+
+	t.Run("deliver due ticks", func(t *testing.T) {
+		c := NewClock(start)
+		ticker := c.NewTicker(time.Second)
+
+		c.Advance(500 * time.Millisecond)
+		select {
+		case <-ticker.C():
+			t.Fatal("could not match tick, got an early one")
+		default:
+		}
+
+		c.Advance(3 * time.Second)
+		select {
+		case got := <-ticker.C():
+			if want := start.Add(3500 * time.Millisecond); !got.Equal(want) {
+				t.Errorf("could not match tick, got: %s. want: %s", got, want)
+			}
+		default:
+			t.Fatal("could not receive tick")
+		}
+
+		select {
+		case <-ticker.C():
+			t.Fatal("could not match tick, got more than one pending")
+		default:
+		}
+
+		ticker.Stop()
+		c.Advance(time.Hour)
+		select {
+		case <-ticker.C():
+			t.Fatal("could not match tick, got one after stop")
+		default:
+		}
+	})
+
+	t.Run("run due timers", func(t *testing.T) {
+		c := NewClock(start)
+		var got []string
+		c.AfterFunc(2*time.Second, func() { got = append(got, "second") })
+		c.AfterFunc(time.Second, func() { got = append(got, "first") })
+		stopped := c.AfterFunc(time.Second, func() { got = append(got, "stopped") })
+
+		if !stopped.Stop() {
+			t.Fatal("could not stop timer")
+		}
+
+		c.Advance(time.Second)
+		c.Advance(time.Second)
+
+		want := []string{"first", "second"}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("could not match timers, got: %v. want: %v", got, want)
+		}
+	})
+}
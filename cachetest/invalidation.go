@@ -0,0 +1,66 @@
+package cachetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/damianopetrungaro/go-cache"
+)
+
+var _ cache.InvalidationBus[string] = &InvalidationBus[string]{}
+
+// InvalidationBus is an in-process cache.InvalidationBus
+// Publish delivers the invalidation synchronously to every subscription, so that tests can assert right after a write
+// It is concurrent safe
+type InvalidationBus[K comparable] struct {
+	mu   sync.RWMutex
+	subs map[*subscription[K]]struct{}
+}
+
+// NewInvalidationBus returns an InvalidationBus
+func NewInvalidationBus[K comparable]() *InvalidationBus[K] {
+	return &InvalidationBus[K]{subs: map[*subscription[K]]struct{}{}}
+}
+
+// Publish calls the handler of every subscription with the invalidation
+func (b *InvalidationBus[K]) Publish(ctx context.Context, inv cache.Invalidation[K]) error {
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %s", cache.ErrNotPublish, ctx.Err())
+	default:
+	}
+
+	b.mu.RLock()
+	subs := make([]*subscription[K], 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		s.handler(inv)
+	}
+	return nil
+}
+
+// Subscribe registers the handler until the returned subscription is closed
+func (b *InvalidationBus[K]) Subscribe(handler func(cache.Invalidation[K])) cache.Subscription {
+	s := &subscription[K]{bus: b, handler: handler}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+type subscription[K comparable] struct {
+	bus     *InvalidationBus[K]
+	handler func(cache.Invalidation[K])
+}
+
+func (s *subscription[K]) Close() error {
+	s.bus.mu.Lock()
+	delete(s.bus.subs, s)
+	s.bus.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,36 @@
+package cachetest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/damianopetrungaro/go-cache"
+	. "github.com/damianopetrungaro/go-cache/cachetest"
+)
+
+func TestInvalidationBus(t *testing.T) {
+	t.Run("deliver to subscriptions until closed", func(t *testing.T) {
+		bus := NewInvalidationBus[string]()
+
+		var got []string
+		sub := bus.Subscribe(func(inv cache.Invalidation[string]) {
+			got = append(got, inv.Origin+" "+inv.Key)
+		})
+
+		if err := bus.Publish(context.Background(), cache.Invalidation[string]{Origin: "a", Key: "one"}); err != nil {
+			t.Fatalf("could not publish invalidation: %s", err)
+		}
+
+		if err := sub.Close(); err != nil {
+			t.Fatalf("could not close subscription: %s", err)
+		}
+
+		if err := bus.Publish(context.Background(), cache.Invalidation[string]{Origin: "a", Key: "two"}); err != nil {
+			t.Fatalf("could not publish invalidation: %s", err)
+		}
+
+		if len(got) != 1 || got[0] != "a one" {
+			t.Errorf("could not match invalidations, got: %v", got)
+		}
+	})
+}
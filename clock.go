@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"time"
+)
+
+// Clock represents the source of time used by the caches
+type Clock interface {
+	Now() time.Time
+	NewTicker(time.Duration) Ticker
+	AfterFunc(time.Duration, func()) Timer
+}
+
+// Ticker represents a ticker returned by a Clock
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer represents a timer returned by a Clock
+type Timer interface {
+	Stop() bool
+}
+
+// systemClock is a Clock relying on the time package
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{Ticker: time.NewTicker(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
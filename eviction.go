@@ -0,0 +1,455 @@
+package cache
+
+import (
+	"container/heap"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ EvictionPolicy[string] = &FIFOPolicy[string]{}
+	_ EvictionPolicy[string] = &LRUPolicy[string]{}
+	_ EvictionPolicy[string] = &LFUPolicy[string]{}
+	_ EvictionPolicy[string] = &ClockPolicy[string]{}
+	_ EvictionPolicy[string] = &RandomPolicy[string]{}
+)
+
+// EvictionReason represents the reason why an item left an InMem
+type EvictionReason int
+
+// List of reasons passed to the OnEvictOption function
+const (
+	EvictionReasonExpired EvictionReason = iota + 1
+	EvictionReasonCapacity
+	EvictionReasonDeleted
+	EvictionReasonReplaced
+)
+
+// String returns the name of the reason
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionReasonExpired:
+		return "expired"
+	case EvictionReasonCapacity:
+		return "capacity"
+	case EvictionReasonDeleted:
+		return "deleted"
+	case EvictionReasonReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictionPolicy represents the strategy used by an InMem to pick the key to evict when the max capacity gets hit
+// It is called while the InMem lock is held, so it does not need to be concurrent safe,
+// but for the same reason an instance must not be shared between caches
+type EvictionPolicy[K comparable] interface {
+	// Access is called when a cached key is read or overwritten
+	Access(K)
+	// Insert is called when a new key is added to the cache
+	Insert(K)
+	// Remove is called when a key leaves the cache, either deleted, expired or evicted
+	Remove(K)
+	// Victim returns the key to evict, false if there is none
+	Victim() (K, bool)
+}
+
+// EvictionPolicyOption represents an InMemOption which uses the given EvictionPolicy when the max capacity gets hit
+// The option can be applied to a single cache, it panics when applied to another one, as done by NewShardedInMemory,
+// since the policy is not concurrent safe: an EvictionPolicyFuncOption must be used instead
+func EvictionPolicyOption[K comparable, V any](p EvictionPolicy[K]) InMemOption[K, V] {
+	var applied int32
+	return func(i *InMem[K, V]) {
+		if !atomic.CompareAndSwapInt32(&applied, 0, 1) {
+			panic("cache: EvictionPolicyOption applied to more than one cache, use EvictionPolicyFuncOption instead")
+		}
+		i.policy = &evictionPolicy[K, V]{policy: p, inmem: i}
+	}
+}
+
+// EvictionPolicyFuncOption represents an InMemOption which uses an EvictionPolicy returned by the given function
+// It is required when the same options create more than one cache, as done by NewShardedInMemory
+func EvictionPolicyFuncOption[K comparable, V any](newPolicy func() EvictionPolicy[K]) InMemOption[K, V] {
+	return func(i *InMem[K, V]) {
+		EvictionPolicyOption[K, V](newPolicy())(i)
+	}
+}
+
+// FIFOPolicy is an EvictionPolicy which evicts the first inserted key
+type FIFOPolicy[K comparable] struct {
+	queue keyList[K]
+}
+
+// NewFIFOPolicy returns a FIFOPolicy
+func NewFIFOPolicy[K comparable]() *FIFOPolicy[K] {
+	return &FIFOPolicy[K]{queue: newKeyList[K]()}
+}
+
+// Access does nothing, the insertion order is not affected by reads
+func (p *FIFOPolicy[K]) Access(K) {}
+
+// Insert enqueues the key
+func (p *FIFOPolicy[K]) Insert(k K) {
+	p.queue.pushFront(k)
+}
+
+// Remove dequeues the key
+func (p *FIFOPolicy[K]) Remove(k K) {
+	p.queue.remove(k)
+}
+
+// Victim returns the first inserted key
+func (p *FIFOPolicy[K]) Victim() (K, bool) {
+	return p.queue.back()
+}
+
+// LRUPolicy is an EvictionPolicy which evicts the least recently used key
+// It behaves as the LRUOption, which should be preferred unless the policy needs to be composed
+type LRUPolicy[K comparable] struct {
+	recent keyList[K]
+}
+
+// NewLRUPolicy returns a LRUPolicy
+func NewLRUPolicy[K comparable]() *LRUPolicy[K] {
+	return &LRUPolicy[K]{recent: newKeyList[K]()}
+}
+
+// Access marks the key as the most recently used
+func (p *LRUPolicy[K]) Access(k K) {
+	p.recent.moveToFront(k)
+}
+
+// Insert marks the key as the most recently used
+func (p *LRUPolicy[K]) Insert(k K) {
+	p.recent.pushFront(k)
+}
+
+// Remove stops tracking the key
+func (p *LRUPolicy[K]) Remove(k K) {
+	p.recent.remove(k)
+}
+
+// Victim returns the least recently used key
+func (p *LRUPolicy[K]) Victim() (K, bool) {
+	return p.recent.back()
+}
+
+// LFUPolicy is an EvictionPolicy which evicts the least frequently used key
+// When two keys have the same frequency the first inserted one gets evicted
+type LFUPolicy[K comparable] struct {
+	entries lfuHeap[K]
+	index   map[K]*lfuEntry[K]
+	seq     uint64
+}
+
+// NewLFUPolicy returns a LFUPolicy
+func NewLFUPolicy[K comparable]() *LFUPolicy[K] {
+	return &LFUPolicy[K]{index: map[K]*lfuEntry[K]{}}
+}
+
+// Access increments the frequency of the key
+func (p *LFUPolicy[K]) Access(k K) {
+	e, ok := p.index[k]
+	if !ok {
+		return
+	}
+
+	e.freq++
+	heap.Fix(&p.entries, e.pos)
+}
+
+// Insert tracks the key with a frequency of one
+func (p *LFUPolicy[K]) Insert(k K) {
+	if _, ok := p.index[k]; ok {
+		p.Access(k)
+		return
+	}
+
+	p.seq++
+	e := &lfuEntry[K]{key: k, freq: 1, seq: p.seq}
+	p.index[k] = e
+	heap.Push(&p.entries, e)
+}
+
+// Remove stops tracking the key
+func (p *LFUPolicy[K]) Remove(k K) {
+	e, ok := p.index[k]
+	if !ok {
+		return
+	}
+
+	delete(p.index, k)
+	heap.Remove(&p.entries, e.pos)
+}
+
+// Victim returns the least frequently used key
+func (p *LFUPolicy[K]) Victim() (K, bool) {
+	if len(p.entries) == 0 {
+		return *new(K), false
+	}
+	return p.entries[0].key, true
+}
+
+type lfuEntry[K comparable] struct {
+	key  K
+	freq uint64
+	seq  uint64
+	pos  int
+}
+
+// lfuHeap is a min heap of entries ordered by frequency and insertion order
+type lfuHeap[K comparable] []*lfuEntry[K]
+
+func (h lfuHeap[K]) Len() int { return len(h) }
+
+func (h lfuHeap[K]) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *lfuHeap[K]) Push(x any) {
+	e := x.(*lfuEntry[K])
+	e.pos = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap[K]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
+
+// ClockPolicy is an EvictionPolicy which approximates an LRU using the CLOCK algorithm
+// Keys sit in a circular buffer with a reference bit set on access,
+// the hand sweeps the buffer clearing the bits and evicts the first key found without it
+type ClockPolicy[K comparable] struct {
+	slots []clockSlot[K]
+	index map[K]int
+	free  []int
+	hand  int
+}
+
+type clockSlot[K comparable] struct {
+	key  K
+	ref  bool
+	used bool
+}
+
+// NewClockPolicy returns a ClockPolicy
+func NewClockPolicy[K comparable]() *ClockPolicy[K] {
+	return &ClockPolicy[K]{index: map[K]int{}}
+}
+
+// Access sets the reference bit of the key
+func (p *ClockPolicy[K]) Access(k K) {
+	if idx, ok := p.index[k]; ok {
+		p.slots[idx].ref = true
+	}
+}
+
+// Insert places the key in a free slot of the buffer
+func (p *ClockPolicy[K]) Insert(k K) {
+	if _, ok := p.index[k]; ok {
+		p.Access(k)
+		return
+	}
+
+	slot := clockSlot[K]{key: k, used: true}
+	if n := len(p.free); n > 0 {
+		idx := p.free[n-1]
+		p.free = p.free[:n-1]
+		p.slots[idx] = slot
+		p.index[k] = idx
+		return
+	}
+
+	p.index[k] = len(p.slots)
+	p.slots = append(p.slots, slot)
+}
+
+// Remove frees the slot of the key
+func (p *ClockPolicy[K]) Remove(k K) {
+	idx, ok := p.index[k]
+	if !ok {
+		return
+	}
+
+	delete(p.index, k)
+	p.slots[idx] = clockSlot[K]{}
+	p.free = append(p.free, idx)
+}
+
+// Victim sweeps the buffer and returns the first key without the reference bit
+func (p *ClockPolicy[K]) Victim() (K, bool) {
+	if len(p.index) == 0 {
+		return *new(K), false
+	}
+
+	// two rounds are enough, the first one clears all the reference bits
+	for i := 0; i < 2*len(p.slots); i++ {
+		if p.hand >= len(p.slots) {
+			p.hand = 0
+		}
+
+		slot := &p.slots[p.hand]
+		p.hand++
+		switch {
+		case !slot.used:
+		case slot.ref:
+			slot.ref = false
+		default:
+			return slot.key, true
+		}
+	}
+
+	return *new(K), false
+}
+
+// RandomPolicy is an EvictionPolicy which evicts a random key
+type RandomPolicy[K comparable] struct {
+	keys  []K
+	index map[K]int
+	rand  *rand.Rand
+}
+
+// NewRandomPolicy returns a RandomPolicy
+func NewRandomPolicy[K comparable]() *RandomPolicy[K] {
+	return &RandomPolicy[K]{
+		index: map[K]int{},
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Access does nothing, the victim is picked regardless of reads
+func (p *RandomPolicy[K]) Access(K) {}
+
+// Insert tracks the key
+func (p *RandomPolicy[K]) Insert(k K) {
+	if _, ok := p.index[k]; ok {
+		return
+	}
+
+	p.index[k] = len(p.keys)
+	p.keys = append(p.keys, k)
+}
+
+// Remove stops tracking the key, swapping it with the last one
+func (p *RandomPolicy[K]) Remove(k K) {
+	idx, ok := p.index[k]
+	if !ok {
+		return
+	}
+
+	last := len(p.keys) - 1
+	p.keys[idx] = p.keys[last]
+	p.index[p.keys[idx]] = idx
+	p.keys[last] = *new(K)
+	p.keys = p.keys[:last]
+	delete(p.index, k)
+}
+
+// Victim returns a random key
+func (p *RandomPolicy[K]) Victim() (K, bool) {
+	if len(p.keys) == 0 {
+		return *new(K), false
+	}
+	return p.keys[p.rand.Intn(len(p.keys))], true
+}
+
+// keyList is a doubly linked list of keys indexed by key, ordered from the front to the back
+type keyList[K comparable] struct {
+	index map[K]*keyNode[K]
+	head  *keyNode[K]
+	tail  *keyNode[K]
+}
+
+type keyNode[K comparable] struct {
+	key  K
+	prev *keyNode[K]
+	next *keyNode[K]
+}
+
+func newKeyList[K comparable]() keyList[K] {
+	return keyList[K]{index: map[K]*keyNode[K]{}}
+}
+
+func (l *keyList[K]) pushFront(k K) {
+	if _, ok := l.index[k]; ok {
+		l.moveToFront(k)
+		return
+	}
+
+	n := &keyNode[K]{key: k}
+	l.index[k] = n
+	l.link(n)
+}
+
+func (l *keyList[K]) moveToFront(k K) {
+	n, ok := l.index[k]
+	if !ok || l.head == n {
+		return
+	}
+
+	l.unlink(n)
+	l.link(n)
+}
+
+func (l *keyList[K]) remove(k K) {
+	n, ok := l.index[k]
+	if !ok {
+		return
+	}
+
+	delete(l.index, k)
+	l.unlink(n)
+}
+
+func (l *keyList[K]) back() (K, bool) {
+	if l.tail == nil {
+		return *new(K), false
+	}
+	return l.tail.key, true
+}
+
+func (l *keyList[K]) link(n *keyNode[K]) {
+	n.prev = nil
+	n.next = l.head
+	if l.head != nil {
+		l.head.prev = n
+	}
+	l.head = n
+	if l.tail == nil {
+		l.tail = n
+	}
+}
+
+func (l *keyList[K]) unlink(n *keyNode[K]) {
+	switch n.prev {
+	case nil:
+		l.head = n.next
+	default:
+		n.prev.next = n.next
+	}
+
+	switch n.next {
+	case nil:
+		l.tail = n.prev
+	default:
+		n.next.prev = n.prev
+	}
+
+	n.prev = nil
+	n.next = nil
+}
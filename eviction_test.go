@@ -0,0 +1,143 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/damianopetrungaro/go-cache"
+)
+
+func TestEvictionPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy  EvictionPolicy[string]
+		reads   []string
+		evicted string
+	}{
+		"fifo": {
+			policy:  NewFIFOPolicy[string](),
+			reads:   []string{"a"},
+			evicted: "a",
+		},
+		"lru": {
+			policy:  NewLRUPolicy[string](),
+			reads:   []string{"a"},
+			evicted: "b",
+		},
+		"lfu": {
+			policy:  NewLFUPolicy[string](),
+			reads:   []string{"a", "a", "c"},
+			evicted: "b",
+		},
+		"clock": {
+			policy:  NewClockPolicy[string](),
+			reads:   []string{"a"},
+			evicted: "b",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			inmem := newInMemHelper(t, EvictionPolicyOption[string, string](test.policy))
+			for _, k := range []string{"a", "b", "c"} {
+				if err := inmem.Set(context.Background(), k, k, NoExpiration); err != nil {
+					t.Fatalf("could not set item: %s", err)
+				}
+			}
+
+			for _, k := range test.reads {
+				if found, _ := inmem.Get(context.Background(), k); found != k {
+					t.Fatalf("could not find item: %s", k)
+				}
+			}
+
+			if err := inmem.Set(context.Background(), "d", "d", NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+
+			for _, k := range []string{"a", "b", "c", "d"} {
+				_, err := inmem.Get(context.Background(), k)
+				switch k {
+				case test.evicted:
+					if !errors.Is(err, ErrNotFound) {
+						t.Errorf("could find item: %s", k)
+					}
+				default:
+					if err != nil {
+						t.Errorf("could not find item: %s", k)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		inmem := newInMemHelper(t, EvictionPolicyOption[string, string](NewRandomPolicy[string]()))
+		for _, k := range []string{"a", "b", "c", "d"} {
+			if err := inmem.Set(context.Background(), k, k, NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		var found int
+		for _, k := range []string{"a", "b", "c", "d"} {
+			if _, err := inmem.Get(context.Background(), k); err == nil {
+				found++
+			}
+		}
+
+		if found != 3 {
+			t.Errorf("could not match items count, got: %d", found)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		p := &recordingPolicy{EvictionPolicy: NewFIFOPolicy[string]()}
+		inmem := NewInMemory[string, string](time.Minute, 1, EvictionPolicyOption[string, string](p))
+		t.Cleanup(func() { _ = inmem.Close() })
+
+		_ = inmem.Set(context.Background(), "a", "a", NoExpiration)
+		_, _ = inmem.Get(context.Background(), "a")
+		_ = inmem.Set(context.Background(), "b", "b", NoExpiration)
+		_ = inmem.Delete(context.Background(), "b")
+
+		want := []string{"insert a", "access a", "insert b", "victim a", "remove a", "remove b"}
+		if len(p.calls) != len(want) {
+			t.Fatalf("could not match calls, got: %v. want: %v", p.calls, want)
+		}
+
+		for i := range want {
+			if p.calls[i] != want[i] {
+				t.Errorf("could not match calls, got: %v. want: %v", p.calls, want)
+			}
+		}
+	})
+}
+
+type recordingPolicy struct {
+	EvictionPolicy[string]
+	calls []string
+}
+
+func (p *recordingPolicy) Access(k string) {
+	p.calls = append(p.calls, "access "+k)
+	p.EvictionPolicy.Access(k)
+}
+
+func (p *recordingPolicy) Insert(k string) {
+	p.calls = append(p.calls, "insert "+k)
+	p.EvictionPolicy.Insert(k)
+}
+
+func (p *recordingPolicy) Remove(k string) {
+	p.calls = append(p.calls, "remove "+k)
+	p.EvictionPolicy.Remove(k)
+}
+
+func (p *recordingPolicy) Victim() (string, bool) {
+	k, ok := p.EvictionPolicy.Victim()
+	p.calls = append(p.calls, "victim "+k)
+	return k, ok
+}
@@ -3,8 +3,8 @@ module github.com/damianopetrungaro/go-cache
 go 1.18
 
 require (
+	github.com/docker/go-connections v0.4.0
 	github.com/go-redis/redis/v9 v9.0.0-beta.1
-	github.com/golang/mock v1.4.1
 	github.com/google/uuid v1.3.0
 	github.com/testcontainers/testcontainers-go v0.13.0
 )
@@ -20,7 +20,6 @@ require (
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/docker/distribution v2.7.1+incompatible // indirect
 	github.com/docker/docker v20.10.11+incompatible // indirect
-	github.com/docker/go-connections v0.4.0 // indirect
 	github.com/docker/go-units v0.4.0 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
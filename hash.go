@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+var seed = maphash.MakeSeed()
+
+// hashKey returns a 64 bit hash of a comparable key, equal keys sharing the same hash.
+// Strings and numbers are hashed without allocating, any other type is hashed by walking its value
+func hashKey[K comparable](k K) uint64 {
+	switch v := any(k).(type) {
+	case string:
+		return hashString(v)
+	case int:
+		return mix(uint64(v))
+	case int8:
+		return mix(uint64(v))
+	case int16:
+		return mix(uint64(v))
+	case int32:
+		return mix(uint64(v))
+	case int64:
+		return mix(uint64(v))
+	case uint:
+		return mix(uint64(v))
+	case uint8:
+		return mix(uint64(v))
+	case uint16:
+		return mix(uint64(v))
+	case uint32:
+		return mix(uint64(v))
+	case uint64:
+		return mix(v)
+	case uintptr:
+		return mix(uint64(v))
+	case float32:
+		// -0 and +0 are equal keys, so they must share the same hash
+		if v == 0 {
+			return mix(0)
+		}
+		return mix(uint64(math.Float32bits(v)))
+	case float64:
+		if v == 0 {
+			return mix(0)
+		}
+		return mix(math.Float64bits(v))
+	default:
+		return hashReflect(k)
+	}
+}
+
+// hashReflect hashes the key by walking its value.
+// It is kept apart from hashKey so that the type switch does not make every key escape to the heap
+func hashReflect[K comparable](k K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	hashValue(&h, reflect.ValueOf(k))
+	return h.Sum64()
+}
+
+// hashValue writes a value to the hash following the equality of the == operator:
+// pointers and channels are hashed by address, structs, arrays and interfaces by their content
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		_ = h.WriteByte(0)
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint64(h, 1)
+			return
+		}
+		writeUint64(h, 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeFloat(h, real(c))
+		writeFloat(h, imag(c))
+	case reflect.String:
+		writeUint64(h, uint64(v.Len()))
+		_, _ = h.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint64(h, uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			_ = h.WriteByte(0)
+			return
+		}
+		hashValue(h, v.Elem())
+	case reflect.Array:
+		for j := 0; j < v.Len(); j++ {
+			hashValue(h, v.Index(j))
+		}
+	case reflect.Struct:
+		for j := 0; j < v.NumField(); j++ {
+			hashValue(h, v.Field(j))
+		}
+	default:
+		// comparable values of other kinds cannot exist, so any hash keeps equal keys together
+		_ = h.WriteByte(0)
+	}
+}
+
+// writeFloat writes a float so that -0 and +0, which are equal keys, share the same hash
+func writeFloat(h *maphash.Hash, f float64) {
+	if f == 0 {
+		writeUint64(h, 0)
+		return
+	}
+	writeUint64(h, math.Float64bits(f))
+}
+
+func writeUint64(h *maphash.Hash, x uint64) {
+	var b [8]byte
+	for j := range b {
+		b[j] = byte(x >> (8 * j))
+	}
+	_, _ = h.Write(b[:])
+}
+
+func hashString(s string) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	_, _ = h.WriteString(s)
+	return h.Sum64()
+}
+
+// mix is the splitmix64 finalizer, it spreads the bits of sequential numbers
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
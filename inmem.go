@@ -5,45 +5,175 @@ import (
 	"fmt"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/damianopetrungaro/go-cache/internal/stats"
+	"github.com/damianopetrungaro/go-cache/internal/wheel"
 )
 
-var _ Cache[string, any] = &InMem[string, any]{}
+var (
+	_ Cache[string, any]      = &InMem[string, any]{}
+	_ TTLer[string]           = &InMem[string, any]{}
+	_ BatchCache[string, any] = &InMem[string, any]{}
+	_ StatsReporter           = &InMem[string, any]{}
+)
 
 type expiresAt int64
 
-func (ea expiresAt) isExpired() bool {
+func (ea expiresAt) isExpired(now int64) bool {
 	i := int64(ea)
-	return time.Now().UnixNano() > i && i != int64(NoExpiration)
+	return now > i && i != int64(NoExpiration)
 }
 
-type item[V any] struct {
+type item[K comparable, V any] struct {
+	key       K
 	val       V
 	expiresAt expiresAt
+	prev      *item[K, V]
+	next      *item[K, V]
+	cost      int64
+	hash      uint64
+	segment   uint8
+	timer     wheel.Timer[K]
+}
+
+// Sizer represents a value able to report its own cost, used by a CostOption without a cost function
+type Sizer interface {
+	Size() int64
+}
+
+// InMemOption represents a function which applies changes to an InMem cache instance
+type InMemOption[K comparable, V any] func(*InMem[K, V])
+
+// LRUOption represents an InMemOption which evicts the least recently used item when the max capacity gets hit
+func LRUOption[K comparable, V any]() InMemOption[K, V] {
+	return func(i *InMem[K, V]) {
+		i.policy = &lru[K, V]{}
+	}
+}
+
+// TinyLFUOption represents an InMemOption which uses a W-TinyLFU policy when the max capacity gets hit.
+// A new item is admitted only if it is estimated to be accessed more frequently than the item it would evict,
+// so one-off scans cannot flush out the frequently accessed items.
+// Its segments are sized from both the capacity and the max cost of a CostOption, whichever the order of the options
+func TinyLFUOption[K comparable, V any]() InMemOption[K, V] {
+	return func(i *InMem[K, V]) {
+		i.policy = &tinyLFU[K, V]{}
+	}
+}
+
+// CostOption represents an InMemOption which bounds the cache by the total cost of its items, on top of the max capacity
+// Items get evicted until the new one fits, and an item costing more than maxCost is rejected with an ErrTooLarge.
+// When cost is nil, the cost of a value implementing Sizer is its size, otherwise it is one
+func CostOption[K comparable, V any](maxCost int64, cost func(V) int64) InMemOption[K, V] {
+	return func(i *InMem[K, V]) {
+		if cost == nil {
+			cost = sizerCost[V]
+		}
+		i.maxCost = maxCost
+		i.cost = cost
+	}
+}
+
+// sharedCostOption represents an InMemOption which accounts the cost of the items to the given total,
+// so that the given number of InMem instances share the same max cost
+func sharedCostOption[K comparable, V any](totalCost *int64, shares int) InMemOption[K, V] {
+	return func(i *InMem[K, V]) {
+		i.totalCost = totalCost
+		i.costShares = shares
+	}
+}
+
+func sizerCost[V any](val V) int64 {
+	if s, ok := any(val).(Sizer); ok {
+		return s.Size()
+	}
+	return 1
+}
+
+// OnEvictOption represents an InMemOption which calls the given function every time an item leaves the cache
+// or its value gets replaced. The function is called after the lock is released, so it can safely call back into the cache
+func OnEvictOption[K comparable, V any](onEvict func(key K, val V, reason EvictionReason)) InMemOption[K, V] {
+	return func(i *InMem[K, V]) {
+		i.onEvict = onEvict
+	}
+}
+
+type eviction[K comparable, V any] struct {
+	key    K
+	val    V
+	reason EvictionReason
+}
+
+// ClockOption represents an InMemOption which uses the given Clock to expire the items and to tick the clean up
+func ClockOption[K comparable, V any](c Clock) InMemOption[K, V] {
+	return func(i *InMem[K, V]) {
+		i.clock = c
+	}
 }
 
 // InMem is a Cache implementation which interacts with an in-memory map
 // It is concurrent safe
 type InMem[K comparable, V any] struct {
-	items  map[K]item[V]
-	cap    int
-	ticker *time.Ticker
-	mu     sync.RWMutex
+	items      map[K]*item[K, V]
+	free       *item[K, V]
+	policy     policy[K, V]
+	cap        int
+	cost       func(V) int64
+	maxCost    int64
+	totalCost  *int64
+	costShares int
+	onEvict    func(K, V, EvictionReason)
+	evicted    []eviction[K, V]
+	clock      Clock
+	ticker     Ticker
+	done       chan struct{}
+	wheel      *wheel.Wheel[K]
+	stats      *stats.Counters
+	mu         sync.RWMutex
 }
 
 // NewInMemory returns a InMem instance
-func NewInMemory[K comparable, V any](cleanUpInterval time.Duration, cap int) *InMem[K, V] {
+func NewInMemory[K comparable, V any](cleanUpInterval time.Duration, cap int, opts ...InMemOption[K, V]) *InMem[K, V] {
 	inmem := &InMem[K, V]{
-		items:  map[K]item[V]{},
-		cap:    cap,
-		ticker: time.NewTicker(cleanUpInterval),
+		items:      map[K]*item[K, V]{},
+		cap:        cap,
+		clock:      systemClock{},
+		stats:      &stats.Counters{},
+		totalCost:  new(int64),
+		costShares: 1,
+		done:       make(chan struct{}),
 	}
 
+	for _, o := range opts {
+		o(inmem)
+	}
+
+	// the tinylfu segments depend on both the capacity and the max cost, so they are sized once every option is applied
+	if _, ok := inmem.policy.(*tinyLFU[K, V]); ok {
+		maxCost := int64(math.MaxInt64)
+		if inmem.cost != nil {
+			maxCost = inmem.maxCost / int64(inmem.costShares)
+		}
+		if maxCost < 1 {
+			maxCost = 1
+		}
+		inmem.policy = newTinyLFU[K, V](inmem.cap, maxCost)
+	}
+
+	inmem.wheel = wheel.New[K](cleanUpInterval, inmem.clock.Now())
+	inmem.ticker = inmem.clock.NewTicker(cleanUpInterval)
 	go func() {
-		for range inmem.ticker.C {
-			inmem.mu.Lock()
-			inmem.cleanup()
-			inmem.mu.Unlock()
+		for {
+			select {
+			case now := <-inmem.ticker.C():
+				inmem.mu.Lock()
+				inmem.wheel.Advance(now, inmem.expire)
+				inmem.unlock()
+			case <-inmem.done:
+				return
+			}
 		}
 	}()
 
@@ -52,31 +182,90 @@ func NewInMemory[K comparable, V any](cleanUpInterval time.Duration, cap int) *I
 
 // Get retrieves an item from an in-memory map
 func (i *InMem[K, V]) Get(ctx context.Context, key K) (V, error) {
+	// recording an access mutates the policy, so it needs an exclusive lock
+	switch i.policy != nil {
+	case true:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+	default:
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+	}
+
+	select {
+	case <-ctx.Done():
+		return *new(V), fmt.Errorf("%w: %s", ErrNotGet, ctx.Err())
+	default:
+	}
+
+	return i.get(key, i.clock.Now().UnixNano())
+}
+
+// GetMany retrieves many items from an in-memory map, holding the lock once
+func (i *InMem[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, map[K]error) {
+	switch i.policy != nil {
+	case true:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+	default:
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+	}
+
+	vals := make(map[K]V, len(keys))
+	var errs map[K]error
+	select {
+	case <-ctx.Done():
+		return vals, manyErrors(keys, fmt.Errorf("%w: %s", ErrNotGet, ctx.Err()))
+	default:
+	}
+
+	now := i.clock.Now().UnixNano()
+	for _, k := range keys {
+		val, err := i.get(k, now)
+		if err != nil {
+			if errs == nil {
+				errs = map[K]error{}
+			}
+			errs[k] = err
+			continue
+		}
+		vals[k] = val
+	}
+	return vals, errs
+}
+
+// TTL returns the ttl left to an item, without recording an access to it
+func (i *InMem[K, V]) TTL(ctx context.Context, key K) (time.Duration, error) {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 
 	select {
 	case <-ctx.Done():
-		return *new(V), fmt.Errorf("%w: %s", ErrNotGet, ctx.Err())
+		return 0, fmt.Errorf("%w: %s", ErrNotGet, ctx.Err())
 	default:
 	}
 
 	item, ok := i.items[key]
 	if !ok {
-		return *new(V), ErrNotFound
+		return 0, ErrNotFound
 	}
 
-	if item.expiresAt.isExpired() {
-		return *new(V), ErrExpired
+	if item.expiresAt == expiresAt(NoExpiration) {
+		return NoExpiration, nil
 	}
 
-	return item.val, nil
+	left := time.Duration(int64(item.expiresAt) - i.clock.Now().UnixNano())
+	if left <= 0 {
+		return 0, ErrExpired
+	}
+	return left, nil
 }
 
 // Set stores an item to an in-memory map
 func (i *InMem[K, V]) Set(ctx context.Context, key K, val V, ttl time.Duration) error {
 	i.mu.Lock()
-	defer i.mu.Unlock()
+	defer i.unlock()
 
 	select {
 	case <-ctx.Done():
@@ -84,24 +273,37 @@ func (i *InMem[K, V]) Set(ctx context.Context, key K, val V, ttl time.Duration)
 	default:
 	}
 
-	exp := expiresAt(ttl)
-	if ttl != NoExpiration {
-		exp = expiresAt(time.Now().Add(ttl).UnixNano())
-	}
+	return i.set(key, val, i.expiresAt(ttl))
+}
 
-	if len(i.items) == i.cap {
-		i.cleanup()
-	}
+// SetMany stores many items to an in-memory map with the same ttl, holding the lock once
+func (i *InMem[K, V]) SetMany(ctx context.Context, items map[K]V, ttl time.Duration) map[K]error {
+	i.mu.Lock()
+	defer i.unlock()
 
-	i.items[key] = item[V]{val: val, expiresAt: exp}
+	select {
+	case <-ctx.Done():
+		return manyErrors(keysOf(items), fmt.Errorf("%w: %s", ErrNotSet, ctx.Err()))
+	default:
+	}
 
-	return nil
+	var errs map[K]error
+	exp := i.expiresAt(ttl)
+	for k, v := range items {
+		if err := i.set(k, v, exp); err != nil {
+			if errs == nil {
+				errs = map[K]error{}
+			}
+			errs[k] = err
+		}
+	}
+	return errs
 }
 
 // Delete removes an item to an in-memory map
 func (i *InMem[K, V]) Delete(ctx context.Context, key K) error {
 	i.mu.Lock()
-	defer i.mu.Unlock()
+	defer i.unlock()
 
 	select {
 	case <-ctx.Done():
@@ -109,43 +311,235 @@ func (i *InMem[K, V]) Delete(ctx context.Context, key K) error {
 	default:
 	}
 
-	delete(i.items, key)
+	if item, ok := i.items[key]; ok {
+		i.remove(item, EvictionReasonDeleted)
+	}
+	i.stats.Deletes.Inc()
 	return nil
 }
 
-// Close stops the inner ticker
+// DeleteMany removes many items from an in-memory map, holding the lock once
+func (i *InMem[K, V]) DeleteMany(ctx context.Context, keys []K) map[K]error {
+	i.mu.Lock()
+	defer i.unlock()
+
+	select {
+	case <-ctx.Done():
+		return manyErrors(keys, fmt.Errorf("%w: %s", ErrNotDelete, ctx.Err()))
+	default:
+	}
+
+	for _, k := range keys {
+		if item, ok := i.items[k]; ok {
+			i.remove(item, EvictionReasonDeleted)
+		}
+	}
+	i.stats.Deletes.Add(len(keys))
+	return nil
+}
+
+// Stats returns the statistics of the cache
+func (i *InMem[K, V]) Stats() Stats {
+	i.mu.RLock()
+	size := len(i.items)
+	i.mu.RUnlock()
+
+	s := newStats(i.stats, size)
+	s.Evictions = evictions(i.stats)
+	return s
+}
+
+// Close stops the inner ticker and the clean up goroutine, it can be called many times
 func (i *InMem[K, V]) Close() error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	i.ticker.Stop()
+	select {
+	case <-i.done:
+	default:
+		i.ticker.Stop()
+		close(i.done)
+	}
+	return nil
+}
+
+// get retrieves an item, the lock must be held
+func (i *InMem[K, V]) get(key K, now int64) (V, error) {
+	item, ok := i.items[key]
+	if !ok {
+		if i.policy != nil {
+			i.policy.access(key, nil)
+		}
+		i.stats.Misses.Inc()
+		return *new(V), ErrNotFound
+	}
+
+	if item.expiresAt.isExpired(now) {
+		i.stats.Expired.Inc()
+		return *new(V), ErrExpired
+	}
+
+	if i.policy != nil {
+		i.policy.access(key, item)
+	}
+
+	i.stats.Hits.Inc()
+	return item.val, nil
+}
+
+// expiresAt returns when an item set now with the given ttl expires
+func (i *InMem[K, V]) expiresAt(ttl time.Duration) expiresAt {
+	if ttl == NoExpiration {
+		return expiresAt(NoExpiration)
+	}
+	return expiresAt(i.clock.Now().Add(ttl).UnixNano())
+}
+
+// set stores an item, the lock must be held
+func (i *InMem[K, V]) set(key K, val V, exp expiresAt) error {
+	var cost int64
+	if i.cost != nil {
+		cost = i.cost(val)
+		if cost > i.maxCost {
+			return ErrTooLarge
+		}
+	}
+
+	if item, ok := i.items[key]; ok {
+		if i.onEvict != nil {
+			i.evicted = append(i.evicted, eviction[K, V]{key: key, val: item.val, reason: EvictionReasonReplaced})
+		}
+		i.stats.Sets.Inc()
+		i.stats.Evictions[EvictionReasonReplaced].Inc()
+		atomic.AddInt64(i.totalCost, cost-item.cost)
+		if i.policy != nil {
+			i.policy.resize(item, cost)
+		}
+		item.val = val
+		item.expiresAt = exp
+		item.cost = cost
+		i.schedule(item)
+		if i.policy != nil {
+			i.policy.access(key, item)
+		}
+		i.evict(item)
+		return nil
+	}
+
+	it := i.newItem()
+	it.key = key
+	it.val = val
+	it.expiresAt = exp
+	it.cost = cost
+	i.items[key] = it
+	i.stats.Sets.Inc()
+	atomic.AddInt64(i.totalCost, cost)
+	i.schedule(it)
+	if i.policy != nil {
+		i.policy.insert(it)
+	}
+	i.evict(it)
+
 	return nil
 }
 
-// cleanup remove all the expired items.
-// if no item is expired, it deletes the one closer to expire
-func (i *InMem[K, V]) cleanup() {
-	ks := []K{}
-	minExp := math.MaxInt64
+// schedule adds the item to the wheel, so that it gets removed within a clean up interval once expired
+func (i *InMem[K, V]) schedule(it *item[K, V]) {
+	switch it.expiresAt {
+	case expiresAt(NoExpiration):
+		i.wheel.Remove(&it.timer)
+	default:
+		i.wheel.Add(&it.timer, it.key, time.Unix(0, int64(it.expiresAt)))
+	}
+}
+
+// expire removes an item whose timer expired in the wheel
+func (i *InMem[K, V]) expire(k K) {
+	if it, ok := i.items[k]; ok {
+		i.remove(it, EvictionReasonExpired)
+	}
+}
+
+// evict removes items until both the capacity and the max cost are respected
+// without a policy, the item closer to expire other than the one just set gets evicted.
+// When the max cost is shared, the rest of it may be held by other instances, so the last item is never evicted for its cost
+func (i *InMem[K, V]) evict(set *item[K, V]) {
+	now := i.clock.Now().UnixNano()
+	for len(i.items) > i.cap || (i.cost != nil && atomic.LoadInt64(i.totalCost) > i.maxCost && len(i.items) > 1) {
+		var victim *item[K, V]
+		switch i.policy {
+		case nil:
+			victim = i.closest(set)
+		default:
+			victim = i.policy.victim()
+		}
+
+		if victim == nil {
+			return
+		}
+
+		reason := EvictionReasonCapacity
+		if victim.expiresAt.isExpired(now) {
+			reason = EvictionReasonExpired
+		}
+		i.remove(victim, reason)
+	}
+}
+
+// closest returns the item closer to expire, skipping the given one.
+// It is taken from the wheel, precise up to a clean up interval, comparing only the items of its earliest slots,
+// and an item never expiring is returned only when no other item is left
+func (i *InMem[K, V]) closest(skip *item[K, V]) *item[K, V] {
+	if k, ok := i.wheel.Earliest(&skip.timer); ok {
+		return i.items[k]
+	}
 
-	for k, item := range i.items {
-		switch {
-		case item.expiresAt.isExpired():
-			delete(i.items, k)
-		case minExp == int(item.expiresAt):
-			minExp = int(item.expiresAt)
-			ks = append(ks, k)
-		case minExp > int(item.expiresAt):
-			minExp = int(item.expiresAt)
-			ks = []K{k}
+	for _, it := range i.items {
+		if it != skip {
+			return it
 		}
 	}
+	return nil
+}
 
-	if len(i.items) < i.cap {
-		return
+// unlock releases the lock, then notifies the evicted items collected while holding it
+// so that the callback can safely call back into the cache
+func (i *InMem[K, V]) unlock() {
+	evicted := i.evicted
+	i.evicted = nil
+	i.mu.Unlock()
+
+	for _, e := range evicted {
+		i.onEvict(e.key, e.val, e.reason)
 	}
+}
 
-	for _, k := range ks {
-		delete(i.items, k)
+// newItem returns an item from the free list, allocating it only when the list is empty
+func (i *InMem[K, V]) newItem() *item[K, V] {
+	it := i.free
+	if it == nil {
+		return &item[K, V]{}
 	}
+
+	i.free = it.next
+	it.next = nil
+	return it
+}
+
+// remove deletes the item from the map and the policy, and moves it to the free list
+func (i *InMem[K, V]) remove(it *item[K, V], reason EvictionReason) {
+	if i.onEvict != nil {
+		i.evicted = append(i.evicted, eviction[K, V]{key: it.key, val: it.val, reason: reason})
+	}
+	i.stats.Evictions[reason].Inc()
+
+	delete(i.items, it.key)
+	i.wheel.Remove(&it.timer)
+	atomic.AddInt64(i.totalCost, -it.cost)
+	if i.policy != nil {
+		i.policy.remove(it)
+	}
+
+	*it = item[K, V]{next: i.free}
+	i.free = it
 }
@@ -3,11 +3,15 @@ package cache_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
 
 	. "github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/cachetest"
 )
 
 func TestInMem(t *testing.T) {
@@ -87,7 +91,8 @@ func TestInMem(t *testing.T) {
 	})
 
 	t.Run("get expired value", func(t *testing.T) {
-		inmem := newInMemHelper(t)
+		clock := cachetest.NewClock(time.Now())
+		inmem := newInMemHelper(t, ClockOption[string, string](clock))
 
 		const k = "key"
 		want := "value"
@@ -95,7 +100,7 @@ func TestInMem(t *testing.T) {
 			t.Fatalf("could not set item: %s", err)
 		}
 
-		time.Sleep(time.Millisecond)
+		clock.Advance(2 * time.Millisecond)
 		val, err := inmem.Get(context.Background(), k)
 		if !errors.Is(err, ErrNotGet) {
 			t.Errorf("could not match not found error. got: %s", err)
@@ -106,6 +111,32 @@ func TestInMem(t *testing.T) {
 		}
 	})
 
+	t.Run("get ttl left", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		inmem := newInMemHelper(t, ClockOption[string, string](clock))
+
+		_ = inmem.Set(context.Background(), "one", "1", time.Minute)
+		_ = inmem.Set(context.Background(), "two", "2", NoExpiration)
+		clock.Advance(time.Second)
+
+		if ttl, err := inmem.TTL(context.Background(), "one"); err != nil || ttl != time.Minute-time.Second {
+			t.Errorf("could not match ttl, got: %s %v. want: %s", ttl, err, time.Minute-time.Second)
+		}
+
+		if ttl, err := inmem.TTL(context.Background(), "two"); err != nil || ttl != NoExpiration {
+			t.Errorf("could not match no expiration ttl, got: %s %v", ttl, err)
+		}
+
+		if _, err := inmem.TTL(context.Background(), "three"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("could not match not found error. got: %s", err)
+		}
+
+		clock.Advance(time.Minute)
+		if _, err := inmem.TTL(context.Background(), "one"); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match expired error. got: %s", err)
+		}
+	})
+
 	t.Run("ensure cleanup behavior when expired item", func(t *testing.T) {
 		inmem := newInMemHelper(t)
 
@@ -187,11 +218,340 @@ func TestInMem(t *testing.T) {
 			t.Fatalf("could not find item: %s", k)
 		}
 	})
+
+	t.Run("ensure cleanup behavior keeps never expiring items", func(t *testing.T) {
+		inmem := NewInMemory[string, string](time.Minute, 3)
+		t.Cleanup(func() { _ = inmem.Close() })
+
+		ttls := map[string]time.Duration{"never": NoExpiration, "short": time.Minute, "mid": 2 * time.Minute, "long": 3 * time.Minute}
+		for _, k := range []string{"never", "short", "mid", "long"} {
+			if err := inmem.Set(context.Background(), k, k, ttls[k]); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		for _, k := range []string{"never", "mid", "long"} {
+			if found, _ := inmem.Get(context.Background(), k); found != k {
+				t.Errorf("could not find item: %s", k)
+			}
+		}
+
+		if _, err := inmem.Get(context.Background(), "short"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("could find item: %s", "short")
+		}
+	})
+
+	t.Run("ensure cleanup removes expired items within a clean up interval", func(t *testing.T) {
+		// the clean up ticker starts at any time, not aligned to the interval
+		clock := cachetest.NewClock(time.Date(2022, 6, 9, 0, 0, 30, 0, time.UTC))
+		expired := make(chan string, 1)
+		inmem := NewInMemory[string, string](
+			time.Minute,
+			3,
+			ClockOption[string, string](clock),
+			OnEvictOption[string, string](func(k, _ string, reason EvictionReason) {
+				if reason == EvictionReasonExpired {
+					expired <- k
+				}
+			}),
+		)
+		t.Cleanup(func() { _ = inmem.Close() })
+
+		const k, v = "key", "value"
+		clock.Advance(10 * time.Second)
+		if err := inmem.Set(context.Background(), k, v, 30*time.Second); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		// the first tick comes 20 seconds after the item expired
+		clock.Advance(50 * time.Second)
+		select {
+		case got := <-expired:
+			if got != k {
+				t.Fatalf("could not match expired item, got: %s", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("could not clean up expired item within a clean up interval")
+		}
+	})
+
+	t.Run("ensure lru eviction keeps recently used items", func(t *testing.T) {
+		inmem := newInMemHelper(t, LRUOption[string, string]())
+
+		const hotK, hotV = "hot key", "hot value"
+		const coldK, coldV = "cold key", "cold value"
+		const midK, midV = "mid key", "mid value"
+		const k, v = "key", "value"
+
+		if err := inmem.Set(context.Background(), hotK, hotV, NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if err := inmem.Set(context.Background(), coldK, coldV, NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if err := inmem.Set(context.Background(), midK, midV, NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if found, _ := inmem.Get(context.Background(), hotK); found != hotV {
+			t.Fatalf("could not find item: %s", hotK)
+		}
+
+		if err := inmem.Set(context.Background(), k, v, NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if found, _ := inmem.Get(context.Background(), hotK); found != hotV {
+			t.Fatalf("could not find item: %s", hotK)
+		}
+
+		if found, _ := inmem.Get(context.Background(), midK); found != midV {
+			t.Fatalf("could not find item: %s", midK)
+		}
+
+		if _, err := inmem.Get(context.Background(), coldK); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("could find item: %s", coldK)
+		}
+
+		if found, _ := inmem.Get(context.Background(), k); found != v {
+			t.Fatalf("could not find item: %s", k)
+		}
+	})
+
+	t.Run("ensure lru cleanup purges expired items", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		expired := make(chan string, 1)
+		inmem := NewInMemory[string, string](
+			time.Second,
+			3,
+			LRUOption[string, string](),
+			ClockOption[string, string](clock),
+			OnEvictOption[string, string](func(k, _ string, _ EvictionReason) { expired <- k }),
+		)
+		t.Cleanup(func() { _ = inmem.Close() })
+
+		const k, v = "key", "value"
+		const expK, expV = "expiring key", "expiring value"
+
+		if err := inmem.Set(context.Background(), k, v, NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if err := inmem.Set(context.Background(), expK, expV, time.Millisecond); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		clock.Advance(time.Second)
+		select {
+		case got := <-expired:
+			if got != expK {
+				t.Fatalf("could not match expired item, got: %s", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("could not clean up expired item")
+		}
+
+		if _, err := inmem.Get(context.Background(), expK); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("could find item: %s", expK)
+		}
+
+		if found, _ := inmem.Get(context.Background(), k); found != v {
+			t.Fatalf("could not find item: %s", k)
+		}
+	})
+
+	t.Run("ensure tinylfu eviction keeps frequently used items on scan", func(t *testing.T) {
+		inmem := NewInMemory[string, string](time.Minute, 100, TinyLFUOption[string, string]())
+		t.Cleanup(func() { _ = inmem.Close() })
+
+		for i := 0; i < 50; i++ {
+			k := fmt.Sprintf("hot key %d", i)
+			if err := inmem.Set(context.Background(), k, k, NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		for r := 0; r < 10; r++ {
+			for i := 0; i < 50; i++ {
+				k := fmt.Sprintf("hot key %d", i)
+				if found, _ := inmem.Get(context.Background(), k); found != k {
+					t.Fatalf("could not find item: %s", k)
+				}
+			}
+		}
+
+		for i := 0; i < 1_000; i++ {
+			k := fmt.Sprintf("scan key %d", i)
+			if err := inmem.Set(context.Background(), k, k, NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		for i := 0; i < 50; i++ {
+			k := fmt.Sprintf("hot key %d", i)
+			if found, _ := inmem.Get(context.Background(), k); found != k {
+				t.Fatalf("could not find item: %s", k)
+			}
+		}
+	})
+
+	t.Run("ensure tinylfu eviction keeps frequently used items on scan with a max cost", func(t *testing.T) {
+		for _, cap := range []int{1_000, math.MaxInt} {
+			inmem := NewInMemory[string, string](time.Minute, cap, TinyLFUOption[string, string](), CostOption[string, string](100, nil))
+			t.Cleanup(func() { _ = inmem.Close() })
+
+			for i := 0; i < 10; i++ {
+				k := fmt.Sprintf("hot key %d", i)
+				if err := inmem.Set(context.Background(), k, k, NoExpiration); err != nil {
+					t.Fatalf("could not set item: %s", err)
+				}
+			}
+
+			for r := 0; r < 10; r++ {
+				for i := 0; i < 10; i++ {
+					k := fmt.Sprintf("hot key %d", i)
+					if found, _ := inmem.Get(context.Background(), k); found != k {
+						t.Fatalf("could not find item: %s", k)
+					}
+				}
+			}
+
+			for i := 0; i < 1_000; i++ {
+				k := fmt.Sprintf("scan key %d", i)
+				if err := inmem.Set(context.Background(), k, k, NoExpiration); err != nil {
+					t.Fatalf("could not set item: %s", err)
+				}
+			}
+
+			for i := 0; i < 10; i++ {
+				k := fmt.Sprintf("hot key %d", i)
+				if found, _ := inmem.Get(context.Background(), k); found != k {
+					t.Fatalf("could not find item with capacity %d: %s", cap, k)
+				}
+			}
+		}
+	})
+
+	t.Run("ensure cost eviction until the item fits", func(t *testing.T) {
+		inmem := NewInMemory[string, string](time.Minute, 100, CostOption[string, string](10, func(v string) int64 {
+			return int64(len(v))
+		}), LRUOption[string, string]())
+		t.Cleanup(func() { _ = inmem.Close() })
+
+		const k1, v1 = "key 1", "12345"
+		const k2, v2 = "key 2", "1234"
+		const k3, v3 = "key 3", "123"
+
+		if err := inmem.Set(context.Background(), k1, v1, NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if err := inmem.Set(context.Background(), k2, v2, NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if err := inmem.Set(context.Background(), k3, v3, NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if _, err := inmem.Get(context.Background(), k1); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("could find item: %s", k1)
+		}
+
+		if found, _ := inmem.Get(context.Background(), k2); found != v2 {
+			t.Fatalf("could not find item: %s", k2)
+		}
+
+		if found, _ := inmem.Get(context.Background(), k3); found != v3 {
+			t.Fatalf("could not find item: %s", k3)
+		}
+	})
+
+	t.Run("ensure cost rejects items larger than the max cost", func(t *testing.T) {
+		inmem := NewInMemory[string, sizedValue](time.Minute, 100, CostOption[string, sizedValue](10, nil))
+		t.Cleanup(func() { _ = inmem.Close() })
+
+		const k = "key"
+		if err := inmem.Set(context.Background(), k, sizedValue(5), NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		err := inmem.Set(context.Background(), k, sizedValue(11), NoExpiration)
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("could not match too large error. got: %s", err)
+		}
+
+		if !errors.Is(err, ErrNotSet) {
+			t.Errorf("could not match not set error. got: %s", err)
+		}
+
+		if found, _ := inmem.Get(context.Background(), k); found != sizedValue(5) {
+			t.Fatalf("could not find item: %s", k)
+		}
+	})
+
+	t.Run("ensure close stops the clean up goroutine", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		for i := 0; i < 100; i++ {
+			inmem := NewInMemory[string, string](time.Minute, 10)
+			if err := inmem.Close(); err != nil {
+				t.Fatalf("could not close inmem: %s", err)
+			}
+			if err := inmem.Close(); err != nil {
+				t.Fatalf("could not close inmem twice: %s", err)
+			}
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		if got := runtime.NumGoroutine(); got > before {
+			t.Errorf("could not stop clean up goroutines, got: %d. want: %d", got, before)
+		}
+	})
+
+	t.Run("ensure evicted items are notified", func(t *testing.T) {
+		var inmem *InMem[string, string]
+		var got []string
+		inmem = NewInMemory[string, string](time.Minute, 2, OnEvictOption[string, string](func(k, v string, reason EvictionReason) {
+			// calling back into the cache must not deadlock
+			_, _ = inmem.Get(context.Background(), k)
+			got = append(got, fmt.Sprintf("%s %s %s", k, v, reason))
+		}), LRUOption[string, string]())
+		t.Cleanup(func() { _ = inmem.Close() })
+
+		_ = inmem.Set(context.Background(), "a", "1", NoExpiration)
+		_ = inmem.Set(context.Background(), "a", "2", NoExpiration)
+		_ = inmem.Set(context.Background(), "b", "3", NoExpiration)
+		_ = inmem.Set(context.Background(), "c", "4", NoExpiration)
+		_ = inmem.Delete(context.Background(), "b")
+
+		want := []string{"a 1 replaced", "a 2 capacity", "b 3 deleted"}
+		if len(got) != len(want) {
+			t.Fatalf("could not match evictions, got: %v. want: %v", got, want)
+		}
+
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("could not match evictions, got: %v. want: %v", got, want)
+			}
+		}
+	})
+}
+
+type sizedValue int64
+
+func (s sizedValue) Size() int64 {
+	return int64(s)
 }
 
-func newInMemHelper(t *testing.T) *InMem[string, string] {
+func newInMemHelper(t *testing.T, opts ...InMemOption[string, string]) *InMem[string, string] {
 	t.Helper()
-	inmem := NewInMemory[string, string](time.Minute, 3)
+	inmem := NewInMemory[string, string](time.Minute, 3, opts...)
 	t.Cleanup(func() {
 		if err := inmem.Close(); err != nil {
 			t.Errorf("could not close inmem: %s", err)
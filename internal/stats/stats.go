@@ -0,0 +1,40 @@
+// Package stats provides the lock-free counters behind the statistics of the caches
+package stats
+
+import (
+	"sync/atomic"
+)
+
+// Counters holds the counters of a cache
+// It must be allocated on its own, so that its counters are 64-bit aligned on 32-bit platforms
+type Counters struct {
+	Hits       Counter
+	Misses     Counter
+	Expired    Counter
+	Errors     Counter
+	Sets       Counter
+	Deletes    Counter
+	LoadErrors Counter
+	// Evictions are indexed by eviction reason
+	Evictions [8]Counter
+}
+
+// Counter is a counter updated atomically, so that it never takes a lock
+type Counter struct {
+	n uint64
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	atomic.AddUint64(&c.n, 1)
+}
+
+// Add adds n to the counter
+func (c *Counter) Add(n int) {
+	atomic.AddUint64(&c.n, uint64(n))
+}
+
+// Load returns the value of the counter
+func (c *Counter) Load() uint64 {
+	return atomic.LoadUint64(&c.n)
+}
@@ -0,0 +1,32 @@
+package stats_test
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/damianopetrungaro/go-cache/internal/stats"
+)
+
+func TestCounters(t *testing.T) {
+	t.Run("count concurrently", func(t *testing.T) {
+		c := &Counters{}
+		wg := sync.WaitGroup{}
+		wg.Add(100)
+		for i := 0; i < 100; i++ {
+			go func() {
+				defer wg.Done()
+				c.Hits.Inc()
+				c.Sets.Add(2)
+			}()
+		}
+		wg.Wait()
+
+		if got := c.Hits.Load(); got != 100 {
+			t.Errorf("could not match hits, got: %d", got)
+		}
+
+		if got := c.Sets.Load(); got != 200 {
+			t.Errorf("could not match sets, got: %d", got)
+		}
+	})
+}
@@ -0,0 +1,218 @@
+// Package wheel provides a hierarchical timing wheel to schedule expirations
+package wheel
+
+import (
+	"time"
+)
+
+const (
+	slotBits = 6
+	slots    = 1 << slotBits
+	slotMask = slots - 1
+	levels   = 6
+	// maxDelta is the furthest deadline a wheel can place, later ones get placed there
+	// and cascade again once it gets reached
+	maxDelta = 1<<(slotBits*levels) - 1
+)
+
+// Timer is a node of a Wheel, it is meant to be embedded in the scheduled value so that scheduling does not allocate
+type Timer[K comparable] struct {
+	Key    K
+	at     int64
+	prev   *Timer[K]
+	next   *Timer[K]
+	bucket *bucket[K]
+}
+
+// Scheduled reports whether the timer is in a wheel
+func (t *Timer[K]) Scheduled() bool {
+	return t.bucket != nil
+}
+
+type bucket[K comparable] struct {
+	head *Timer[K]
+}
+
+func (b *bucket[K]) push(t *Timer[K]) {
+	t.bucket = b
+	t.prev = nil
+	t.next = b.head
+	if b.head != nil {
+		b.head.prev = t
+	}
+	b.head = t
+}
+
+func (b *bucket[K]) remove(t *Timer[K]) {
+	switch t.prev {
+	case nil:
+		b.head = t.next
+	default:
+		t.prev.next = t.next
+	}
+
+	if t.next != nil {
+		t.next.prev = t.prev
+	}
+
+	t.prev = nil
+	t.next = nil
+	t.bucket = nil
+}
+
+// Wheel is a hierarchical timing wheel with a fixed tick granularity
+// Every level has 64 slots, each slot of a level spans a whole rotation of the level below,
+// so advancing the wheel touches only the due slot and, once per rotation, cascades a slot of the level above.
+// The ticks are counted from the time the wheel starts, so that they match the ones of a ticker started along with it.
+// It is not concurrent safe
+type Wheel[K comparable] struct {
+	tick   int64
+	origin int64
+	now    int64
+	count  int
+	levels [levels][slots]bucket[K]
+}
+
+// New returns a Wheel ticking every tick starting from now
+func New[K comparable](tick time.Duration, now time.Time) *Wheel[K] {
+	if tick <= 0 {
+		tick = time.Nanosecond
+	}
+
+	return &Wheel[K]{
+		tick:   int64(tick),
+		origin: now.UnixNano(),
+	}
+}
+
+// Len returns the number of scheduled timers
+func (w *Wheel[K]) Len() int {
+	return w.count
+}
+
+// Add schedules the timer to expire at the given time, rescheduling it when it is already in the wheel
+// A timer is never expired before its time, and its time is rounded up to the next tick from the start of the wheel,
+// so when Advance is called every tick since the start, as by a ticker started along with the wheel,
+// a timer is expired less than a tick after its time
+func (w *Wheel[K]) Add(t *Timer[K], k K, at time.Time) {
+	if t.Scheduled() {
+		w.Remove(t)
+	}
+
+	t.Key = k
+	t.at = (at.UnixNano() - w.origin + w.tick - 1) / w.tick
+	if t.at <= w.now {
+		t.at = w.now + 1
+	}
+
+	w.place(t)
+	w.count++
+}
+
+// Remove unschedules the timer
+func (w *Wheel[K]) Remove(t *Timer[K]) {
+	if !t.Scheduled() {
+		return
+	}
+
+	t.bucket.remove(t)
+	w.count--
+}
+
+// Advance moves the wheel to the given time, calling expire for the key of every due timer
+// expire can remove timers, but must not add them
+func (w *Wheel[K]) Advance(now time.Time, expire func(K)) {
+	target := (now.UnixNano() - w.origin) / w.tick
+	for w.now < target {
+		if w.count == 0 {
+			w.now = target
+			return
+		}
+
+		w.now++
+		w.cascade()
+
+		b := &w.levels[0][w.now&slotMask]
+		for t := b.head; t != nil; t = b.head {
+			b.remove(t)
+			w.count--
+			expire(t.Key)
+		}
+	}
+}
+
+// Earliest returns the key of the timer expiring first, skipping the given timer, which can be nil.
+// It is precise up to a tick: the earliest timer of every level is in its first non empty slot,
+// so only the timers of one slot per level get compared
+func (w *Wheel[K]) Earliest(skip *Timer[K]) (K, bool) {
+	var earliest *Timer[K]
+	for l := 0; l < levels; l++ {
+		cur := w.now >> (slotBits * l)
+		// the current slot of the lowest level is already expired, so it is the last one to be reached
+		if l == 0 {
+			cur++
+		}
+
+		for i := int64(0); i < slots; i++ {
+			found := false
+			for t := w.levels[l][(cur+i)&slotMask].head; t != nil; t = t.next {
+				if t == skip {
+					continue
+				}
+
+				found = true
+				if earliest == nil || t.at < earliest.at {
+					earliest = t
+				}
+			}
+
+			if found {
+				break
+			}
+		}
+	}
+
+	if earliest == nil {
+		return *new(K), false
+	}
+	return earliest.Key, true
+}
+
+// cascade moves the timers of the slots reached by the current tick to the levels below
+// The highest level goes first, so that its timers can still be cascaded by the levels below within the same tick
+func (w *Wheel[K]) cascade() {
+	top := 0
+	for top < levels-1 && (w.now>>(slotBits*top))&slotMask == 0 {
+		top++
+	}
+
+	for l := top; l > 0; l-- {
+		b := &w.levels[l][(w.now>>(slotBits*l))&slotMask]
+		for t := b.head; t != nil; t = b.head {
+			b.remove(t)
+			w.place(t)
+		}
+	}
+}
+
+// place links the timer in the slot of the lowest level able to hold its deadline
+func (w *Wheel[K]) place(t *Timer[K]) {
+	at := t.at
+	if at < w.now {
+		at = w.now
+	}
+
+	delta := at - w.now
+	if delta > maxDelta {
+		delta = maxDelta
+		at = w.now + maxDelta
+	}
+
+	l := 0
+	for delta >= slots && l < levels-1 {
+		delta >>= slotBits
+		l++
+	}
+
+	w.levels[l][(at>>(slotBits*l))&slotMask].push(t)
+}
@@ -0,0 +1,159 @@
+package wheel_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	. "github.com/damianopetrungaro/go-cache/internal/wheel"
+)
+
+func TestWheel(t *testing.T) {
+	start := time.Date(2022, 6, 9, 0, 0, 0, 0, time.UTC)
+
+	t.Run("expire due timers only", func(t *testing.T) {
+		w := New[string](time.Second, start)
+		var soon, late Timer[string]
+		w.Add(&soon, "soon", start.Add(1500*time.Millisecond))
+		w.Add(&late, "late", start.Add(time.Hour))
+
+		var got []string
+		w.Advance(start.Add(time.Second), func(k string) { got = append(got, k) })
+		if len(got) != 0 {
+			t.Fatalf("could not match expired keys, got: %v", got)
+		}
+
+		w.Advance(start.Add(2*time.Second), func(k string) { got = append(got, k) })
+		if len(got) != 1 || got[0] != "soon" {
+			t.Fatalf("could not match expired keys, got: %v", got)
+		}
+
+		if w.Len() != 1 {
+			t.Errorf("could not match scheduled timers, got: %d", w.Len())
+		}
+	})
+
+	t.Run("remove and reschedule timers", func(t *testing.T) {
+		w := New[string](time.Second, start)
+		var removed, moved Timer[string]
+		w.Add(&removed, "removed", start.Add(time.Second))
+		w.Add(&moved, "moved", start.Add(time.Second))
+		w.Remove(&removed)
+		w.Add(&moved, "moved", start.Add(time.Minute))
+
+		var got []string
+		w.Advance(start.Add(time.Second), func(k string) { got = append(got, k) })
+		if len(got) != 0 {
+			t.Fatalf("could not match expired keys, got: %v", got)
+		}
+
+		w.Advance(start.Add(time.Minute), func(k string) { got = append(got, k) })
+		if len(got) != 1 || got[0] != "moved" {
+			t.Fatalf("could not match expired keys, got: %v", got)
+		}
+
+		if removed.Scheduled() || moved.Scheduled() {
+			t.Error("could not match scheduled state")
+		}
+	})
+
+	t.Run("expire within one tick across levels", func(t *testing.T) {
+		const tick = time.Millisecond
+		w := New[int](tick, start)
+		r := rand.New(rand.NewSource(1))
+
+		deadlines := map[int]time.Time{}
+		timers := make([]Timer[int], 2_000)
+		for i := range timers {
+			// deadlines spread over the first three levels
+			at := start.Add(time.Duration(r.Int63n(int64(70 * 64 * 64 * tick))))
+			deadlines[i] = at
+			w.Add(&timers[i], i, at)
+		}
+
+		now := start
+		for w.Len() > 0 {
+			now = now.Add(time.Duration(r.Int63n(int64(50*tick))) + tick/2)
+			w.Advance(now, func(k int) {
+				if now.Before(deadlines[k]) {
+					t.Fatalf("could not match expiry, %d expired early at %s. deadline: %s", k, now, deadlines[k])
+				}
+
+				// the wheel expires within a tick, the rest is the random step of the test
+				if late := now.Sub(deadlines[k]); late > 52*tick {
+					t.Fatalf("could not match expiry, %d expired %s late", k, late)
+				}
+				delete(deadlines, k)
+			})
+		}
+
+		if len(deadlines) != 0 {
+			t.Errorf("could not match expired timers, %d left", len(deadlines))
+		}
+	})
+
+	t.Run("expire within a tick when advanced every tick", func(t *testing.T) {
+		const tick = time.Millisecond
+		// the wheel starts at any time, as a ticker started along with it
+		unaligned := start.Add(tick / 3)
+		w := New[int](tick, unaligned)
+		r := rand.New(rand.NewSource(1))
+
+		deadlines := map[int]time.Time{}
+		timers := make([]Timer[int], 1_000)
+		for i := range timers {
+			at := unaligned.Add(time.Duration(r.Int63n(int64(100 * tick))))
+			deadlines[i] = at
+			w.Add(&timers[i], i, at)
+		}
+
+		for now := unaligned; w.Len() > 0; {
+			now = now.Add(tick)
+			w.Advance(now, func(k int) {
+				if late := now.Sub(deadlines[k]); late < 0 || late >= tick {
+					t.Fatalf("could not match expiry, %d expired %s late", k, late)
+				}
+			})
+		}
+	})
+
+	t.Run("return the earliest timer", func(t *testing.T) {
+		w := New[string](time.Second, start)
+		if _, ok := w.Earliest(nil); ok {
+			t.Fatal("could not match empty wheel")
+		}
+
+		var soon, mid, late Timer[string]
+		w.Add(&late, "late", start.Add(time.Hour))
+		w.Add(&mid, "mid", start.Add(2*time.Minute))
+		w.Add(&soon, "soon", start.Add(30*time.Second))
+
+		for _, want := range []struct {
+			skip *Timer[string]
+			key  string
+		}{{skip: nil, key: "soon"}, {skip: &soon, key: "mid"}} {
+			if got, ok := w.Earliest(want.skip); !ok || got != want.key {
+				t.Errorf("could not match earliest timer, got: %s. want: %s", got, want.key)
+			}
+		}
+
+		w.Remove(&soon)
+		w.Remove(&mid)
+		var higher, lower Timer[string]
+		w.Add(&higher, "higher", start.Add(200*time.Second))
+		w.Advance(start.Add(150*time.Second), func(string) {})
+
+		// the timer due in 50 seconds is still in a higher level, while the one due in 60 seconds is in the lowest one
+		w.Add(&lower, "lower", start.Add(210*time.Second))
+		if got, _ := w.Earliest(nil); got != "higher" {
+			t.Errorf("could not match earliest timer across levels, got: %s", got)
+		}
+
+		w.Remove(&higher)
+		w.Remove(&lower)
+		w.Advance(start.Add(30*time.Minute), func(string) {})
+		if got, _ := w.Earliest(nil); got != "late" {
+			t.Errorf("could not match earliest timer after advancing, got: %s", got)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Invalidation represents a key changed by a MultiLevel, to be removed from the upper levels of the other instances
+type Invalidation[K comparable] struct {
+	// Origin identifies the MultiLevel which published the invalidation, so that it can ignore its own ones
+	Origin string `json:"origin"`
+	Key    K      `json:"key"`
+}
+
+// InvalidationBus represents the contract for broadcasting invalidations between MultiLevel instances
+type InvalidationBus[K comparable] interface {
+	Publish(context.Context, Invalidation[K]) error
+	// Subscribe calls the handler for every invalidation published, including the ones of the subscriber,
+	// until the returned Subscription is closed
+	Subscribe(handler func(Invalidation[K])) Subscription
+}
+
+// Subscription represents a subscription to an InvalidationBus
+type Subscription interface {
+	Close() error
+}
+
+// newOrigin returns a random identifier for the invalidations published by a MultiLevel
+func newOrigin() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,59 @@
+package cache
+
+// list is an intrusive doubly linked list of items.
+// The front holds the most recently used item, the back the least recently used one
+type list[K comparable, V any] struct {
+	head *item[K, V]
+	tail *item[K, V]
+	len  int
+}
+
+// pushFront links the item at the front of the list
+func (l *list[K, V]) pushFront(it *item[K, V]) {
+	it.prev = nil
+	it.next = l.head
+	if l.head != nil {
+		l.head.prev = it
+	}
+	l.head = it
+	if l.tail == nil {
+		l.tail = it
+	}
+	l.len++
+}
+
+// remove unlinks the item from the list
+func (l *list[K, V]) remove(it *item[K, V]) {
+	switch it.prev {
+	case nil:
+		l.head = it.next
+	default:
+		it.prev.next = it.next
+	}
+
+	switch it.next {
+	case nil:
+		l.tail = it.prev
+	default:
+		it.next.prev = it.prev
+	}
+
+	it.prev = nil
+	it.next = nil
+	l.len--
+}
+
+// moveToFront moves an already linked item at the front of the list
+func (l *list[K, V]) moveToFront(it *item[K, V]) {
+	if l.head == it {
+		return
+	}
+
+	l.remove(it)
+	l.pushFront(it)
+}
+
+// back returns the least recently used item, nil if the list is empty
+func (l *list[K, V]) back() *item[K, V] {
+	return l.tail
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/damianopetrungaro/go-cache/internal/stats"
+)
+
+var (
+	_ Cache[string, any] = &Loader[string, any]{}
+	_ StatsReporter      = &Loader[string, any]{}
+)
+
+// LoadFunc represents a function loading a value missing in the cache, together with the ttl to store it with
+type LoadFunc[V any] func(context.Context) (V, time.Duration, error)
+
+// Loader is a Cache implementation which wraps a Cache to load the values missing in it
+// Concurrent loads of the same key are collapsed into a single call
+// It is concurrent safe
+type Loader[K comparable, V any] struct {
+	cache Cache[K, V]
+	mu    sync.Mutex
+	calls map[K]*call[V]
+	stats *stats.Counters
+}
+
+// call is a load in flight shared by all the callers waiting for it
+type call[V any] struct {
+	done   chan struct{}
+	val    V
+	err    error
+	panic  *loadPanic
+	refs   int
+	cancel context.CancelFunc
+}
+
+// NewLoader returns a Loader wrapping the given Cache
+func NewLoader[K comparable, V any](c Cache[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		cache: c,
+		calls: map[K]*call[V]{},
+		stats: &stats.Counters{},
+	}
+}
+
+// GetOrLoad retrieves an item from the wrapped cache, if an error occurred it loads the item and stores it.
+// The load runs once for all the concurrent callers of the same key, with a context carrying the values of the first caller.
+// A caller whose context is done stops waiting, and the load gets canceled once no caller is waiting for it anymore
+// A panic of the LoadFunc is propagated to all the callers waiting for it, carrying the stack trace of the load
+func (l *Loader[K, V]) GetOrLoad(ctx context.Context, k K, load LoadFunc[V]) (V, error) {
+	if val, err := l.cache.Get(ctx, k); err == nil {
+		return val, nil
+	}
+
+	l.mu.Lock()
+	c, ok := l.calls[k]
+	if !ok {
+		loadCtx, cancel := context.WithCancel(detachedContext{Context: ctx})
+		c = &call[V]{done: make(chan struct{}), cancel: cancel}
+		l.calls[k] = c
+		go l.load(loadCtx, k, c, load)
+	}
+	c.refs++
+	l.mu.Unlock()
+
+	select {
+	case <-c.done:
+		if c.panic != nil {
+			panic(c.panic)
+		}
+		return c.val, c.err
+	case <-ctx.Done():
+		l.mu.Lock()
+		c.refs--
+		if c.refs == 0 && l.calls[k] == c {
+			delete(l.calls, k)
+			c.cancel()
+		}
+		l.mu.Unlock()
+		return *new(V), fmt.Errorf("%w: %s", ErrNotGet, ctx.Err())
+	}
+}
+
+// Get retrieves an item from the wrapped cache
+func (l *Loader[K, V]) Get(ctx context.Context, k K) (V, error) {
+	return l.cache.Get(ctx, k)
+}
+
+// Set stores an item to the wrapped cache
+func (l *Loader[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
+	return l.cache.Set(ctx, k, v, ttl)
+}
+
+// Delete removes an item from the wrapped cache
+func (l *Loader[K, V]) Delete(ctx context.Context, k K) error {
+	return l.cache.Delete(ctx, k)
+}
+
+// Stats returns the statistics of the wrapped cache together with the failed loads
+func (l *Loader[K, V]) Stats() Stats {
+	s := statsOf(l.cache)
+	s.LoadErrors += l.stats.LoadErrors.Load()
+	return s
+}
+
+// load runs the LoadFunc and stores its value, then releases the callers waiting for it.
+// A panic of the LoadFunc is recovered, so that the callers panic instead of crashing the process from this goroutine
+func (l *Loader[K, V]) load(ctx context.Context, k K, c *call[V], load LoadFunc[V]) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.stats.LoadErrors.Inc()
+			c.panic = &loadPanic{value: r, stack: debug.Stack()}
+		}
+		l.mu.Lock()
+		if l.calls[k] == c {
+			delete(l.calls, k)
+		}
+		l.mu.Unlock()
+		c.cancel()
+		close(c.done)
+	}()
+
+	val, ttl, err := load(ctx)
+	switch err {
+	case nil:
+		c.val = val
+		// the loaded value is returned even if it cannot be cached
+		_ = l.cache.Set(ctx, k, val, ttl)
+	default:
+		l.stats.LoadErrors.Inc()
+		c.err = loadError{err: err}
+	}
+}
+
+// loadPanic is the value the callers panic with when the LoadFunc panicked,
+// keeping the value of the panic and the stack trace of the goroutine running the load
+type loadPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *loadPanic) Error() string {
+	return fmt.Sprintf("load panicked: %v\n\n%s", p.value, p.stack)
+}
+
+func (p *loadPanic) Unwrap() error {
+	err, _ := p.value.(error)
+	return err
+}
+
+// loadError is an ErrNotLoad which keeps the error returned by the LoadFunc in the chain
+type loadError struct {
+	err error
+}
+
+func (e loadError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrNotLoad, e.err)
+}
+
+func (e loadError) Is(target error) bool {
+	return errors.Is(ErrNotLoad, target)
+}
+
+func (e loadError) Unwrap() error {
+	return e.err
+}
+
+// detachedContext is a context carrying the values of the parent one, without its deadline and cancellation
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
@@ -0,0 +1,188 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/damianopetrungaro/go-cache"
+)
+
+func TestLoader(t *testing.T) {
+	t.Run("load missing value", func(t *testing.T) {
+		loader := NewLoader[string, string](newInMemHelper(t))
+
+		const k = "key"
+		var calls int
+		load := func(context.Context) (string, time.Duration, error) {
+			calls++
+			return "value", NoExpiration, nil
+		}
+
+		for i := 0; i < 2; i++ {
+			got, err := loader.GetOrLoad(context.Background(), k, load)
+			if err != nil {
+				t.Fatalf("could not load item: %s", err)
+			}
+
+			if got != "value" {
+				t.Errorf("could not match value, got: %s. want:%s", got, "value")
+			}
+		}
+
+		if calls != 1 {
+			t.Errorf("could not match load calls, got: %d", calls)
+		}
+	})
+
+	t.Run("collapse concurrent loads", func(t *testing.T) {
+		loader := NewLoader[string, string](newInMemHelper(t))
+
+		var calls int64
+		release := make(chan struct{})
+		load := func(context.Context) (string, time.Duration, error) {
+			atomic.AddInt64(&calls, 1)
+			<-release
+			return "value", NoExpiration, nil
+		}
+
+		const c = 100
+		wg := sync.WaitGroup{}
+		wg.Add(c)
+		for i := 0; i < c; i++ {
+			go func() {
+				defer wg.Done()
+				if got, err := loader.GetOrLoad(context.Background(), "key", load); err != nil || got != "value" {
+					t.Errorf("could not load item, got: %s. err: %s", got, err)
+				}
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if calls != 1 {
+			t.Errorf("could not match load calls, got: %d", calls)
+		}
+	})
+
+	t.Run("return load error", func(t *testing.T) {
+		loader := NewLoader[string, string](newInMemHelper(t))
+
+		wantErr := errors.New("database is down")
+		_, err := loader.GetOrLoad(context.Background(), "key", func(context.Context) (string, time.Duration, error) {
+			return "", NoExpiration, wantErr
+		})
+
+		if !errors.Is(err, ErrNotLoad) || !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not load error. got: %s", err)
+		}
+
+		if !errors.Is(err, wantErr) {
+			t.Errorf("could not match load error. got: %s", err)
+		}
+	})
+
+	t.Run("propagate load panic to the callers", func(t *testing.T) {
+		loader := NewLoader[string, string](newInMemHelper(t))
+
+		release := make(chan struct{})
+		load := func(context.Context) (string, time.Duration, error) {
+			<-release
+			panic("database driver bug")
+		}
+
+		wg := sync.WaitGroup{}
+		recovered := make([]any, 2)
+		for i := range recovered {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { recovered[i] = recover() }()
+				_, _ = loader.GetOrLoad(context.Background(), "key", load)
+			}(i)
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		for _, r := range recovered {
+			err, ok := r.(error)
+			if !ok || !strings.Contains(err.Error(), "database driver bug") || !strings.Contains(err.Error(), "goroutine") {
+				t.Errorf("could not propagate load panic with its stack trace. got: %v", r)
+			}
+		}
+
+		got, err := loader.GetOrLoad(context.Background(), "key", func(context.Context) (string, time.Duration, error) {
+			return "value", NoExpiration, nil
+		})
+		if err != nil || got != "value" {
+			t.Errorf("could not load after a panic, got: %s %v", got, err)
+		}
+	})
+
+	t.Run("cancel load when no caller is waiting", func(t *testing.T) {
+		loader := NewLoader[string, string](newInMemHelper(t))
+
+		canceled := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+		load := func(ctx context.Context) (string, time.Duration, error) {
+			cancel()
+			<-ctx.Done()
+			close(canceled)
+			return "", NoExpiration, ctx.Err()
+		}
+
+		if _, err := loader.GetOrLoad(ctx, "key", load); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not get error. got: %s", err)
+		}
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("could not cancel load")
+		}
+	})
+
+	t.Run("keep loading while a caller is waiting", func(t *testing.T) {
+		loader := NewLoader[string, string](newInMemHelper(t))
+
+		var once sync.Once
+		started, release := make(chan struct{}), make(chan struct{})
+		load := func(ctx context.Context) (string, time.Duration, error) {
+			once.Do(func() { close(started) })
+			<-release
+			return "value", NoExpiration, ctx.Err()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error)
+		go func() {
+			_, err := loader.GetOrLoad(ctx, "key", load)
+			errs <- err
+		}()
+		<-started
+
+		vals := make(chan string)
+		go func() {
+			got, _ := loader.GetOrLoad(context.Background(), "key", load)
+			vals <- got
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		if err := <-errs; !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not get error. got: %s", err)
+		}
+
+		close(release)
+		if got := <-vals; got != "value" {
+			t.Errorf("could not match value, got: %s. want:%s", got, "value")
+		}
+	})
+}
@@ -0,0 +1,7 @@
+module github.com/damianopetrungaro/go-cache/logging
+
+go 1.21
+
+require github.com/damianopetrungaro/go-cache v0.0.0
+
+replace github.com/damianopetrungaro/go-cache v0.0.0 => ./../
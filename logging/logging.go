@@ -0,0 +1,257 @@
+// Package logging logs the operations of the caches with log/slog
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/damianopetrungaro/go-cache"
+)
+
+var (
+	_ cache.Cache[string, any]      = &Logging[string, any]{}
+	_ cache.BatchCache[string, any] = &Logging[string, any]{}
+)
+
+// List of operations logged as the op attribute
+const (
+	opGet        = "get"
+	opSet        = "set"
+	opDelete     = "delete"
+	opGetMany    = "get_many"
+	opSetMany    = "set_many"
+	opDeleteMany = "delete_many"
+)
+
+// Option represents a function which applies changes to a Logging cache instance
+type Option[K comparable, V any] func(*Logging[K, V])
+
+// SlowThresholdOption represents an Option which logs at warn level the operations lasting at least the given duration
+// It defaults to 100ms, zero disables it
+func SlowThresholdOption[K comparable, V any](threshold time.Duration) Option[K, V] {
+	return func(l *Logging[K, V]) {
+		l.slow = threshold
+	}
+}
+
+// HitSampleOption represents an Option which logs at debug level one hit every n. It defaults to zero, logging none
+func HitSampleOption[K comparable, V any](n uint64) Option[K, V] {
+	return func(l *Logging[K, V]) {
+		l.sample = n
+	}
+}
+
+// KeyRedactorOption represents an Option which logs the keys as returned by the given function
+// It defaults to HashKey, so that the keys are not leaked to the logs
+func KeyRedactorOption[K comparable, V any](redact func(K) slog.Value) Option[K, V] {
+	return func(l *Logging[K, V]) {
+		l.redact = redact
+	}
+}
+
+// ContextAttrsOption represents an Option which adds the attributes returned by the given function
+// from the context passed to every operation, such as a request id.
+// It defaults to the attributes added by ContextWithAttrs
+func ContextAttrsOption[K comparable, V any](attrs func(context.Context) []slog.Attr) Option[K, V] {
+	return func(l *Logging[K, V]) {
+		l.ctxAttrs = attrs
+	}
+}
+
+// Logging is a cache.Cache implementation which wraps a cache.Cache to log its operations with a slog.Logger.
+// The errors are logged by sentinel: misses (cache.ErrNotFound and cache.ErrExpired) at debug level,
+// cache.ErrNotSet and cache.ErrNotDelete at warn level, and any other error at error level.
+// Slow operations are logged at warn level, and hits can be sampled at debug level.
+// The context passed to every operation is passed to the slog.Handler, and nothing is allocated when the level is disabled
+// It is concurrent safe
+type Logging[K comparable, V any] struct {
+	cache    cache.Cache[K, V]
+	logger   *slog.Logger
+	slow     time.Duration
+	sample   uint64
+	hits     atomic.Uint64
+	redact   func(K) slog.Value
+	ctxAttrs func(context.Context) []slog.Attr
+}
+
+// New returns a Logging wrapping the given cache.Cache, logging with the given slog.Logger
+func New[K comparable, V any](c cache.Cache[K, V], logger *slog.Logger, opts ...Option[K, V]) *Logging[K, V] {
+	l := &Logging[K, V]{
+		cache:    c,
+		logger:   logger,
+		slow:     100 * time.Millisecond,
+		redact:   HashKey[K],
+		ctxAttrs: attrsFromContext,
+	}
+
+	for _, o := range opts {
+		o(l)
+	}
+
+	return l
+}
+
+// Get retrieves an item from the wrapped cache, logging the failure, the slowness or a sampled hit
+func (l *Logging[K, V]) Get(ctx context.Context, k K) (V, error) {
+	start := time.Now()
+	val, err := l.cache.Get(ctx, k)
+	elapsed := time.Since(start)
+
+	if err == nil && !l.isSlow(elapsed) {
+		l.hit(ctx, k, elapsed)
+		return val, nil
+	}
+
+	l.observe(ctx, opGet, k, elapsed, err)
+	return val, err
+}
+
+// Set stores an item to the wrapped cache, logging the failure or the slowness
+func (l *Logging[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
+	start := time.Now()
+	err := l.cache.Set(ctx, k, v, ttl)
+	l.observe(ctx, opSet, k, time.Since(start), err)
+	return err
+}
+
+// Delete removes an item from the wrapped cache, logging the failure or the slowness
+func (l *Logging[K, V]) Delete(ctx context.Context, k K) error {
+	start := time.Now()
+	err := l.cache.Delete(ctx, k)
+	l.observe(ctx, opDelete, k, time.Since(start), err)
+	return err
+}
+
+// GetMany retrieves many items from the wrapped cache, logging the failure of every key or the slowness
+func (l *Logging[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, map[K]error) {
+	start := time.Now()
+	vals, errs := cache.GetMany(ctx, l.cache, keys)
+	l.observeMany(ctx, opGetMany, len(keys), time.Since(start), errs)
+	return vals, errs
+}
+
+// SetMany stores many items to the wrapped cache, logging the failure of every key or the slowness
+func (l *Logging[K, V]) SetMany(ctx context.Context, items map[K]V, ttl time.Duration) map[K]error {
+	start := time.Now()
+	errs := cache.SetMany(ctx, l.cache, items, ttl)
+	l.observeMany(ctx, opSetMany, len(items), time.Since(start), errs)
+	return errs
+}
+
+// DeleteMany removes many items from the wrapped cache, logging the failure of every key or the slowness
+func (l *Logging[K, V]) DeleteMany(ctx context.Context, keys []K) map[K]error {
+	start := time.Now()
+	errs := cache.DeleteMany(ctx, l.cache, keys)
+	l.observeMany(ctx, opDeleteMany, len(keys), time.Since(start), errs)
+	return errs
+}
+
+// observe logs the outcome of a single key operation
+// A slow operation is logged at warn level at least
+func (l *Logging[K, V]) observe(ctx context.Context, op string, k K, elapsed time.Duration, err error) {
+	level, msg := slog.LevelInfo, ""
+	switch {
+	case err != nil:
+		level, msg = levelOf(err), "cache operation failed"
+		if l.isSlow(elapsed) && level < slog.LevelWarn {
+			level = slog.LevelWarn
+		}
+	case l.isSlow(elapsed):
+		level, msg = slog.LevelWarn, "slow cache operation"
+	default:
+		return
+	}
+
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	l.log(ctx, level, msg, op, elapsed, err, slog.Attr{Key: "key", Value: l.redact(k)})
+}
+
+// observeMany logs the failures of a many keys operation, or its slowness when none failed
+func (l *Logging[K, V]) observeMany(ctx context.Context, op string, n int, elapsed time.Duration, errs map[K]error) {
+	keys := slog.Int("keys", n)
+	if len(errs) == 0 {
+		if l.isSlow(elapsed) && l.logger.Enabled(ctx, slog.LevelWarn) {
+			l.log(ctx, slog.LevelWarn, "slow cache operation", op, elapsed, nil, keys)
+		}
+		return
+	}
+
+	for k, err := range errs {
+		level := levelOf(err)
+		if l.isSlow(elapsed) && level < slog.LevelWarn {
+			level = slog.LevelWarn
+		}
+
+		if l.logger.Enabled(ctx, level) {
+			l.log(ctx, level, "cache operation failed", op, elapsed, err, keys, slog.Attr{Key: "key", Value: l.redact(k)})
+		}
+	}
+}
+
+// hit logs one hit every sample at debug level
+func (l *Logging[K, V]) hit(ctx context.Context, k K, elapsed time.Duration) {
+	if l.sample == 0 || !l.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	if l.hits.Add(1)%l.sample != 0 {
+		return
+	}
+	l.log(ctx, slog.LevelDebug, "cache hit", opGet, elapsed, nil, slog.Attr{Key: "key", Value: l.redact(k)})
+}
+
+// log writes a record with the operation, its duration, its error, the given attributes and the ones of the context
+func (l *Logging[K, V]) log(ctx context.Context, level slog.Level, msg, op string, elapsed time.Duration, err error, attrs ...slog.Attr) {
+	attrs = append(attrs, slog.String("op", op), slog.Duration("duration", elapsed))
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	attrs = append(attrs, l.ctxAttrs(ctx)...)
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (l *Logging[K, V]) isSlow(elapsed time.Duration) bool {
+	return l.slow > 0 && elapsed >= l.slow
+}
+
+// levelOf returns the level of an error by its sentinel
+func levelOf(err error) slog.Level {
+	switch cache.ClassifyError(err) {
+	case cache.ErrorClassMiss:
+		return slog.LevelDebug
+	case cache.ErrorClassNotSet, cache.ErrorClassNotDelete:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// HashKey is a key redactor returning the key redacted by cache.RedactKey
+func HashKey[K comparable](k K) slog.Value {
+	return slog.StringValue(cache.RedactKey(k))
+}
+
+// PlainKey is a key redactor returning the key as it is, for keys which are not sensitive
+func PlainKey[K comparable](k K) slog.Value {
+	return slog.AnyValue(k)
+}
+
+type attrsKey struct{}
+
+// ContextWithAttrs returns a context carrying the given attributes, together with the ones of the parent context,
+// which are logged by a Logging receiving it
+func ContextWithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent := attrsFromContext(ctx)
+	all := make([]slog.Attr, 0, len(parent)+len(attrs))
+	all = append(all, parent...)
+	return context.WithValue(ctx, attrsKey{}, append(all, attrs...))
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
@@ -0,0 +1,262 @@
+package logging_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/damianopetrungaro/go-cache"
+	. "github.com/damianopetrungaro/go-cache/logging"
+)
+
+func TestLogging(t *testing.T) {
+	t.Run("log errors by sentinel", func(t *testing.T) {
+		h := &recordHandler{level: slog.LevelDebug}
+		l := New[string, string](failingCache{}, slog.New(h))
+
+		_, _ = l.Get(context.Background(), "key")
+		_ = l.Set(context.Background(), "key", "value", time.Minute)
+		_ = l.Delete(context.Background(), "key")
+
+		want := []slog.Level{slog.LevelError, slog.LevelWarn, slog.LevelWarn}
+		records := h.get()
+		if len(records) != len(want) {
+			t.Fatalf("could not match records, got: %d", len(records))
+		}
+
+		for i, r := range records {
+			if r.Level != want[i] || r.Message != "cache operation failed" {
+				t.Errorf("could not match record, got: %s %s. want: %s", r.Level, r.Message, want[i])
+			}
+
+			if attrs(r)["error"] == nil {
+				t.Errorf("could not match error attribute, got: %v", attrs(r))
+			}
+		}
+	})
+
+	t.Run("log misses at debug level", func(t *testing.T) {
+		h := &recordHandler{level: slog.LevelDebug}
+		l := New[string, string](newInMemHelper(t), slog.New(h))
+
+		_, _ = l.Get(context.Background(), "missing")
+
+		records := h.get()
+		if len(records) != 1 || records[0].Level != slog.LevelDebug {
+			t.Fatalf("could not match miss record, got: %v", records)
+		}
+
+		if got := attrs(records[0])["key"]; got == "missing" || got == nil {
+			t.Errorf("could not redact key, got: %v", got)
+		}
+	})
+
+	t.Run("log slow operations", func(t *testing.T) {
+		h := &recordHandler{level: slog.LevelInfo}
+		l := New[string, string](
+			slowCache{Cache: newInMemHelper(t), delay: 2 * time.Millisecond},
+			slog.New(h),
+			SlowThresholdOption[string, string](time.Millisecond),
+			KeyRedactorOption[string, string](PlainKey[string]),
+		)
+
+		_ = l.Set(context.Background(), "key", "value", time.Minute)
+		_, _ = l.Get(context.Background(), "key")
+		_, _ = l.Get(context.Background(), "missing")
+
+		records := h.get()
+		if len(records) != 3 {
+			t.Fatalf("could not match records, got: %d", len(records))
+		}
+
+		for _, r := range records {
+			if r.Level != slog.LevelWarn {
+				t.Errorf("could not match level of %s, got: %s", r.Message, r.Level)
+			}
+
+			if got := attrs(r)["key"]; got != "key" && got != "missing" {
+				t.Errorf("could not match plain key, got: %v", got)
+			}
+		}
+	})
+
+	t.Run("sample hits", func(t *testing.T) {
+		h := &recordHandler{level: slog.LevelDebug}
+		l := New[string, string](newInMemHelper(t), slog.New(h), HitSampleOption[string, string](10))
+
+		_ = l.Set(context.Background(), "key", "value", time.Minute)
+		for i := 0; i < 100; i++ {
+			_, _ = l.Get(context.Background(), "key")
+		}
+
+		records := h.get()
+		if len(records) != 10 {
+			t.Fatalf("could not match sampled hits, got: %d", len(records))
+		}
+
+		if records[0].Message != "cache hit" || records[0].Level != slog.LevelDebug {
+			t.Errorf("could not match hit record, got: %s %s", records[0].Level, records[0].Message)
+		}
+	})
+
+	t.Run("add attributes from the context", func(t *testing.T) {
+		h := &recordHandler{level: slog.LevelDebug}
+		l := New[string, string](failingCache{}, slog.New(h))
+
+		ctx := ContextWithAttrs(context.Background(), slog.String("request_id", "abc"))
+		ctx = ContextWithAttrs(ctx, slog.String("user_id", "123"))
+		_, _ = l.Get(ctx, "key")
+
+		records := h.get()
+		if len(records) != 1 {
+			t.Fatalf("could not match records, got: %d", len(records))
+		}
+
+		got := attrs(records[0])
+		if got["request_id"] != "abc" || got["user_id"] != "123" {
+			t.Errorf("could not match context attributes, got: %v", got)
+		}
+
+		if h.contexts[0] != ctx {
+			t.Error("could not pass the context to the handler")
+		}
+	})
+
+	t.Run("extract attributes from the context", func(t *testing.T) {
+		type requestIDKey struct{}
+		h := &recordHandler{level: slog.LevelDebug}
+		l := New[string, string](failingCache{}, slog.New(h), ContextAttrsOption[string, string](func(ctx context.Context) []slog.Attr {
+			id, _ := ctx.Value(requestIDKey{}).(string)
+			return []slog.Attr{slog.String("request_id", id)}
+		}))
+
+		_ = l.Delete(context.WithValue(context.Background(), requestIDKey{}, "abc"), "key")
+
+		if got := attrs(h.get()[0]); got["request_id"] != "abc" {
+			t.Errorf("could not match context attributes, got: %v", got)
+		}
+	})
+
+	t.Run("log failures of many keys", func(t *testing.T) {
+		h := &recordHandler{level: slog.LevelDebug}
+		l := New[string, string](failingCache{}, slog.New(h))
+
+		_ = l.SetMany(context.Background(), map[string]string{"one": "1", "two": "2"}, time.Minute)
+
+		records := h.get()
+		if len(records) != 2 || records[0].Level != slog.LevelWarn || attrs(records[0])["keys"] != int64(2) {
+			t.Errorf("could not match records, got: %v", records)
+		}
+	})
+
+	t.Run("do not allocate when disabled", func(t *testing.T) {
+		l := New[string, string](newInMemHelper(t), slog.New(&recordHandler{level: slog.LevelInfo}), HitSampleOption[string, string](1))
+		_ = l.Set(context.Background(), "key", "value", cache.NoExpiration)
+
+		ctx := context.Background()
+		allocs := testing.AllocsPerRun(1000, func() {
+			_, _ = l.Get(ctx, "key")
+			_, _ = l.Get(ctx, "missing")
+		})
+
+		if allocs != 0 {
+			t.Errorf("could not match allocations, got: %f", allocs)
+		}
+	})
+}
+
+func BenchmarkLogging(b *testing.B) {
+	inmem := cache.NewInMemory[string, string](time.Minute, 100)
+	defer inmem.Close()
+	l := New[string, string](inmem, slog.New(&recordHandler{level: slog.LevelInfo}))
+	_ = l.Set(context.Background(), "key", "value", cache.NoExpiration)
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = l.Get(ctx, "key")
+	}
+}
+
+func newInMemHelper(t *testing.T) *cache.InMem[string, string] {
+	t.Helper()
+	inmem := cache.NewInMemory[string, string](time.Minute, 100)
+	t.Cleanup(func() {
+		if err := inmem.Close(); err != nil {
+			t.Errorf("could not close inmem: %s", err)
+		}
+	})
+	return inmem
+}
+
+func attrs(r slog.Record) map[string]any {
+	got := map[string]any{}
+	r.Attrs(func(a slog.Attr) bool {
+		got[a.Key] = a.Value.Any()
+		return true
+	})
+	return got
+}
+
+// recordHandler records the records and their contexts from the given level
+type recordHandler struct {
+	level    slog.Level
+	mu       sync.Mutex
+	records  []slog.Record
+	contexts []context.Context
+}
+
+func (h *recordHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	h.contexts = append(h.contexts, ctx)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordHandler) WithGroup(string) slog.Handler { return h }
+
+func (h *recordHandler) get() []slog.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]slog.Record{}, h.records...)
+}
+
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) (string, error) {
+	return "", cache.ErrNotGet
+}
+
+func (failingCache) Set(context.Context, string, string, time.Duration) error {
+	return cache.ErrNotSet
+}
+
+func (failingCache) Delete(context.Context, string) error {
+	return cache.ErrNotDelete
+}
+
+// slowCache delays every operation
+type slowCache struct {
+	cache.Cache[string, string]
+	delay time.Duration
+}
+
+func (c slowCache) Get(ctx context.Context, k string) (string, error) {
+	time.Sleep(c.delay)
+	return c.Cache.Get(ctx, k)
+}
+
+func (c slowCache) Set(ctx context.Context, k string, v string, ttl time.Duration) error {
+	time.Sleep(c.delay)
+	return c.Cache.Set(ctx, k, v, ttl)
+}
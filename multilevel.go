@@ -2,72 +2,555 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/damianopetrungaro/go-cache/internal/stats"
 )
 
 var (
+	_ Cache[string, any]      = &MultiLevel[string, any]{}
+	_ BatchCache[string, any] = &MultiLevel[string, any]{}
+	_ StatsReporter           = &MultiLevel[string, any]{}
+)
+
+var (
+	// ErrInvalidLevels is the error returned by NewMultiLevel when no level is given or a level has no cache
+	ErrInvalidLevels = errors.New("could not create multi level cache with invalid levels")
 	// DefaultMultiLevelExpiration is a constant used to mark an item to expire to the default value passed to a MultiLevel
 	DefaultMultiLevelExpiration = time.Duration(-1)
 )
 
+// WriteStrategy represents how a MultiLevel propagates a Set to its levels
+type WriteStrategy int
+
+// List of strategies accepted by a WriteStrategyOption
+const (
+	// WriteThrough stores the item in every level, starting from the last one
+	WriteThrough WriteStrategy = iota
+	// WriteInvalidate stores the item in the last level only, deleting it from the ones above
+	WriteInvalidate
+)
+
+// Backfill represents how a MultiLevel populates the levels above the one serving a read
+type Backfill int
+
+// List of backfills accepted by a BackfillOption
+const (
+	// NoBackfill leaves the levels above untouched
+	NoBackfill Backfill = iota
+	// BackfillDefaultTTL stores the item in the levels above using their default ttl
+	BackfillDefaultTTL
+	// BackfillRemainingTTL stores the item in the levels above using the ttl left to it in the level serving the read,
+	// bounded by their default ttl. When the level is not a TTLer it behaves as BackfillDefaultTTL
+	BackfillRemainingTTL
+)
+
+// generationSlots is the number of slots the keys are spread over to count their writes
+const generationSlots = 256
+
+// Level represents a cache level of a MultiLevel
+type Level[K comparable, V any] struct {
+	Cache Cache[K, V]
+	// DefaultTTL is the ttl used by the level when DefaultMultiLevelExpiration is passed
+	DefaultTTL time.Duration
+}
+
+// MultiLevelOption represents a function which applies changes to a MultiLevel cache instance
+type MultiLevelOption[K comparable, V any] func(*MultiLevel[K, V])
+
+// MultiLevelClockOption represents a MultiLevelOption which uses the given Clock to measure the time spent writing to the levels
+func MultiLevelClockOption[K comparable, V any](c Clock) MultiLevelOption[K, V] {
+	return func(m *MultiLevel[K, V]) {
+		m.clock = c
+	}
+}
+
+// WriteStrategyOption represents a MultiLevelOption which propagates a Set to the levels using the given WriteStrategy
+func WriteStrategyOption[K comparable, V any](s WriteStrategy) MultiLevelOption[K, V] {
+	return func(m *MultiLevel[K, V]) {
+		m.write = s
+	}
+}
+
+// BackfillOption represents a MultiLevelOption which populates the levels above the one serving a read using the given Backfill
+// The levels are populated in the background, so the read does not wait for them
+func BackfillOption[K comparable, V any](b Backfill) MultiLevelOption[K, V] {
+	return func(m *MultiLevel[K, V]) {
+		m.backfill = b
+	}
+}
+
+// InvalidationBusOption represents a MultiLevelOption which keeps the upper levels of many MultiLevel instances consistent.
+// Every Set and Delete publishes an Invalidation to the bus, and the invalidations published by the other instances
+// remove the key from the upper levels. Publishing is best effort: on failure the other instances serve the stale
+// item until it expires
+func InvalidationBusOption[K comparable, V any](bus InvalidationBus[K]) MultiLevelOption[K, V] {
+	return func(m *MultiLevel[K, V]) {
+		m.bus = bus
+	}
+}
+
 // MultiLevel is a Cache implementation which allow a multi level usage cache
+// The levels are ordered from the fastest to the slowest one, the last level being the source of truth
 type MultiLevel[K comparable, V any] struct {
-	local            *InMem[K, V]
-	defaultLocalTTL  time.Duration
-	remote           Cache[K, V]
-	defaultRemoteTTL time.Duration
+	levels   []Level[K, V]
+	write    WriteStrategy
+	backfill Backfill
+	clock    Clock
+	bus      InvalidationBus[K]
+	origin   string
+	sub      Subscription
+	mu       sync.Mutex
+	filling  map[K]struct{}
+	wg       sync.WaitGroup
+	stats    *stats.Counters
+	// levelStats count the operations made on every level
+	levelStats []*stats.Counters
+	// gens count the writes of the keys spread over slots, so that a backfill can tell when its key was written meanwhile
+	gens []uint64
 }
 
-// NewMultiLevel returns a MultiLevel
-func NewMultiLevel[K comparable, V any](
-	local *InMem[K, V],
-	defaultLocalTTL time.Duration,
-	remote Cache[K, V],
-	defaultRemoteTTL time.Duration,
-) *MultiLevel[K, V] {
-	return &MultiLevel[K, V]{
-		local:            local,
-		defaultLocalTTL:  defaultLocalTTL,
-		remote:           remote,
-		defaultRemoteTTL: defaultRemoteTTL,
+// NewMultiLevel returns a MultiLevel, it fails with an ErrInvalidLevels when no level is given or a level has no cache
+func NewMultiLevel[K comparable, V any](levels []Level[K, V], opts ...MultiLevelOption[K, V]) (*MultiLevel[K, V], error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidLevels, "no levels given")
+	}
+	for i, l := range levels {
+		if l.Cache == nil {
+			return nil, fmt.Errorf("%w: level %d has no cache", ErrInvalidLevels, i)
+		}
+	}
+
+	m := &MultiLevel[K, V]{
+		levels:     levels,
+		clock:      systemClock{},
+		filling:    map[K]struct{}{},
+		gens:       make([]uint64, generationSlots),
+		stats:      &stats.Counters{},
+		levelStats: make([]*stats.Counters, len(levels)),
+	}
+
+	for i := range m.levelStats {
+		m.levelStats[i] = &stats.Counters{}
+	}
+
+	for _, o := range opts {
+		o(m)
 	}
+
+	if m.bus != nil {
+		m.origin = newOrigin()
+		m.sub = m.bus.Subscribe(m.invalidate)
+	}
+
+	return m, nil
 }
 
-// Get search in local cache first, if an error occurred moves to the remote one
+// Get search the levels in order, if all of them fail it returns the error of the last one
+// An item found below the first level is backfilled to the levels above following the Backfill
 func (m *MultiLevel[K, V]) Get(ctx context.Context, k K) (V, error) {
-	val, err := m.local.Get(ctx, k)
-	if err != nil {
-		return m.remote.Get(ctx, k)
+	var err error
+	var gen uint64
+	for i, l := range m.levels {
+		if i == 1 && m.backfill != NoBackfill {
+			gen = m.generation(k)
+		}
+
+		var val V
+		val, err = l.Cache.Get(ctx, k)
+		recordGet(m.levelStats[i], err)
+		if err == nil {
+			if i > 0 && m.backfill != NoBackfill {
+				m.backfillAsync(ctx, map[K]filled[V]{k: {val: val, gen: gen}}, i)
+			}
+			m.stats.Hits.Inc()
+			return val, nil
+		}
 	}
-	return val, nil
+	recordGet(m.stats, err)
+	return *new(V), err
 }
 
-// Set traverse all the caches, if all of them fail it returns a generic ErrNotSet
-func (m *MultiLevel[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
-	if ttl == DefaultMultiLevelExpiration {
-		ttl = m.defaultRemoteTTL
+// GetMany search the levels in order, asking each level only for the keys missed by the ones above
+// The errors are the ones of the last level
+func (m *MultiLevel[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, map[K]error) {
+	vals := make(map[K]V, len(keys))
+	var errs map[K]error
+	var gens map[K]uint64
+	for i, l := range m.levels {
+		if i == 1 && m.backfill != NoBackfill {
+			gens = make(map[K]uint64, len(keys))
+			for _, k := range keys {
+				gens[k] = m.generation(k)
+			}
+		}
+
+		var found map[K]V
+		found, errs = GetMany(ctx, l.Cache, keys)
+		for k, v := range found {
+			vals[k] = v
+		}
+		recordGetMany(m.levelStats[i], found, errs)
+
+		if i > 0 && m.backfill != NoBackfill && len(found) > 0 {
+			fill := make(map[K]filled[V], len(found))
+			for k, v := range found {
+				fill[k] = filled[V]{val: v, gen: gens[k]}
+			}
+			m.backfillAsync(ctx, fill, i)
+		}
+
+		if len(errs) == 0 {
+			break
+		}
+
+		missing := make([]K, 0, len(errs))
+		for _, k := range keys {
+			if _, ok := errs[k]; ok {
+				missing = append(missing, k)
+			}
+		}
+		keys = missing
 	}
-	if err := m.remote.Set(ctx, k, v, ttl); err != nil {
+	recordGetMany(m.stats, vals, errs)
+	return vals, errs
+}
+
+// Set stores the item in the last level, if it fails it returns its error.
+// The levels above are then updated following the WriteStrategy, and their failures are ignored.
+// An item in a level never outlives the one in the level below
+func (m *MultiLevel[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
+	last := len(m.levels) - 1
+	start := m.clock.Now()
+	lastTTL := m.ttl(last, ttl)
+	if err := m.levels[last].Cache.Set(ctx, k, v, lastTTL); err != nil {
 		return err
 	}
+	m.levelStats[last].Sets.Inc()
+	m.bump(k)
 
-	if ttl == DefaultMultiLevelExpiration {
-		ttl = m.defaultLocalTTL
+	// the levels above are only copies, they are updated even when the ctx is done
+	// to not leave a stale item behind
+	upperCtx := detachedContext{Context: ctx}
+	deadline := expiry(start, lastTTL)
+	for i := last - 1; i >= 0; i-- {
+		if m.write == WriteInvalidate {
+			m.delete(upperCtx, i, k)
+			continue
+		}
+
+		levelTTL, levelDeadline, ok := m.bound(m.ttl(i, ttl), deadline)
+		if !ok {
+			m.delete(upperCtx, i, k)
+			continue
+		}
+
+		deadline = levelDeadline
+		m.set(upperCtx, i, k, v, levelTTL)
 	}
-	// in memory won't fail apart from having a ctx done.
-	// passing a background to prevent it
-	_ = m.local.Set(context.Background(), k, v, ttl)
+
+	m.stats.Sets.Inc()
+	m.publish(upperCtx, k)
 	return nil
 }
 
-// Delete traverse all the caches, if all of them fail it returns a generic ErrNotDelete
+// SetMany stores the items in the last level, returning its errors.
+// The items stored are then propagated to the levels above as Set does
+func (m *MultiLevel[K, V]) SetMany(ctx context.Context, items map[K]V, ttl time.Duration) map[K]error {
+	last := len(m.levels) - 1
+	start := m.clock.Now()
+	lastTTL := m.ttl(last, ttl)
+	errs := SetMany(ctx, m.levels[last].Cache, items, lastTTL)
+	if len(errs) > 0 {
+		stored := make(map[K]V, len(items))
+		for k, v := range items {
+			if _, ok := errs[k]; !ok {
+				stored[k] = v
+			}
+		}
+		items = stored
+	}
+
+	if len(items) == 0 {
+		return errs
+	}
+	m.levelStats[last].Sets.Add(len(items))
+
+	upperCtx := detachedContext{Context: ctx}
+	keys := keysOf(items)
+	for _, k := range keys {
+		m.bump(k)
+	}
+	deadline := expiry(start, lastTTL)
+	for i := last - 1; i >= 0; i-- {
+		if m.write == WriteInvalidate {
+			m.deleteMany(upperCtx, i, keys)
+			continue
+		}
+
+		levelTTL, levelDeadline, ok := m.bound(m.ttl(i, ttl), deadline)
+		if !ok {
+			m.deleteMany(upperCtx, i, keys)
+			continue
+		}
+
+		deadline = levelDeadline
+		levelErrs := SetMany(upperCtx, m.levels[i].Cache, items, levelTTL)
+		m.levelStats[i].Sets.Add(len(items) - len(levelErrs))
+	}
+
+	m.stats.Sets.Add(len(items))
+	for _, k := range keys {
+		m.publish(upperCtx, k)
+	}
+	return errs
+}
+
+// Delete removes the item from the last level, if it fails it returns its error.
+// The item is then removed from the levels above, and their failures are ignored
 func (m *MultiLevel[K, V]) Delete(ctx context.Context, k K) error {
-	if err := m.remote.Delete(ctx, k); err != nil {
+	last := len(m.levels) - 1
+	if err := m.levels[last].Cache.Delete(ctx, k); err != nil {
 		return err
 	}
+	m.levelStats[last].Deletes.Inc()
+	m.bump(k)
 
-	// in memory won't fail apart from having a ctx done.
-	// passing a background to prevent it
-	_ = m.local.Delete(context.Background(), k)
+	upperCtx := detachedContext{Context: ctx}
+	for i := last - 1; i >= 0; i-- {
+		m.delete(upperCtx, i, k)
+	}
+
+	m.stats.Deletes.Inc()
+	m.publish(upperCtx, k)
 	return nil
 }
+
+// DeleteMany removes the items from the last level, returning its errors.
+// The items removed are then removed from the levels above as Delete does
+func (m *MultiLevel[K, V]) DeleteMany(ctx context.Context, keys []K) map[K]error {
+	last := len(m.levels) - 1
+	errs := DeleteMany(ctx, m.levels[last].Cache, keys)
+	if len(errs) > 0 {
+		deleted := make([]K, 0, len(keys))
+		for _, k := range keys {
+			if _, ok := errs[k]; !ok {
+				deleted = append(deleted, k)
+			}
+		}
+		keys = deleted
+	}
+
+	if len(keys) == 0 {
+		return errs
+	}
+	m.levelStats[last].Deletes.Add(len(keys))
+	for _, k := range keys {
+		m.bump(k)
+	}
+
+	upperCtx := detachedContext{Context: ctx}
+	for i := last - 1; i >= 0; i-- {
+		m.deleteMany(upperCtx, i, keys)
+	}
+
+	m.stats.Deletes.Add(len(keys))
+	for _, k := range keys {
+		m.publish(upperCtx, k)
+	}
+	return errs
+}
+
+// Stats returns the statistics of the reads and writes made to the MultiLevel, together with the ones of every level.
+// The statistics of a level count the operations made on it by the MultiLevel, such as the reads it absorbed,
+// while its evictions and size are the ones reported by its cache. The size is the one of the last level
+func (m *MultiLevel[K, V]) Stats() Stats {
+	levels := make([]Stats, len(m.levels))
+	for i, l := range m.levels {
+		reported := statsOf(l.Cache)
+		levels[i] = newStats(m.levelStats[i], reported.Size)
+		levels[i].Evictions = reported.Evictions
+	}
+
+	s := newStats(m.stats, levels[len(levels)-1].Size)
+	s.Levels = levels
+	return s
+}
+
+// Close stops receiving invalidations and waits for the backfills running in the background
+func (m *MultiLevel[K, V]) Close() error {
+	var err error
+	if m.sub != nil {
+		err = m.sub.Close()
+	}
+	m.wg.Wait()
+	return err
+}
+
+// publish notifies the other instances that the key changed
+func (m *MultiLevel[K, V]) publish(ctx context.Context, k K) {
+	if m.bus == nil {
+		return
+	}
+	_ = m.bus.Publish(ctx, Invalidation[K]{Origin: m.origin, Key: k})
+}
+
+// invalidate removes the key of an invalidation published by another instance from the upper levels
+func (m *MultiLevel[K, V]) invalidate(inv Invalidation[K]) {
+	if inv.Origin == m.origin {
+		return
+	}
+
+	m.bump(inv.Key)
+	for i := range m.levels[:len(m.levels)-1] {
+		m.delete(context.Background(), i, inv.Key)
+	}
+}
+
+// filled is an item found below the first level, together with the generation of its key read before finding it
+type filled[V any] struct {
+	val V
+	gen uint64
+}
+
+// backfillAsync stores the items found in the given level to the levels above, skipping the ones whose backfill is already running
+// It is best effort: failures are ignored
+func (m *MultiLevel[K, V]) backfillAsync(ctx context.Context, items map[K]filled[V], level int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fill := make(map[K]filled[V], len(items))
+	for k, v := range items {
+		if _, ok := m.filling[k]; !ok {
+			m.filling[k] = struct{}{}
+			fill[k] = v
+		}
+	}
+
+	if len(fill) == 0 {
+		return
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			for k := range fill {
+				delete(m.filling, k)
+			}
+			m.mu.Unlock()
+		}()
+
+		ctx := detachedContext{Context: ctx}
+		for k, f := range fill {
+			m.backfillItem(ctx, k, f, level)
+		}
+	}()
+}
+
+// backfillItem stores the item found in the given level to the levels above.
+// When the key gets written after the item was read, the item is stale: it is not stored,
+// or it is removed from the levels already filled, since the write may have happened before storing it
+func (m *MultiLevel[K, V]) backfillItem(ctx context.Context, k K, f filled[V], level int) {
+	if m.generation(k) != f.gen {
+		return
+	}
+
+	var deadline time.Time
+	if t, ok := m.levels[level].Cache.(TTLer[K]); ok && m.backfill == BackfillRemainingTTL {
+		left, err := t.TTL(ctx, k)
+		if err != nil {
+			return
+		}
+		deadline = expiry(m.clock.Now(), left)
+	}
+
+	for i := level - 1; i >= 0; i-- {
+		ttl, levelDeadline, ok := m.bound(m.levels[i].DefaultTTL, deadline)
+		if !ok {
+			return
+		}
+
+		deadline = levelDeadline
+		m.set(ctx, i, k, f.val, ttl)
+		if m.generation(k) != f.gen {
+			for j := i; j < level; j++ {
+				m.delete(ctx, j, k)
+			}
+			return
+		}
+	}
+}
+
+// generation returns the number of writes counted for the slot of the key
+func (m *MultiLevel[K, V]) generation(k K) uint64 {
+	return atomic.LoadUint64(&m.gens[hashKey(k)%generationSlots])
+}
+
+// bump counts a write of the key, after it reached the last level and before it reaches the levels above.
+// Keys sharing the same slot only make a backfill skip an item which is still fresh
+func (m *MultiLevel[K, V]) bump(k K) {
+	if m.backfill == NoBackfill {
+		return
+	}
+	atomic.AddUint64(&m.gens[hashKey(k)%generationSlots], 1)
+}
+
+// set stores the item in an upper level, ignoring its failure
+func (m *MultiLevel[K, V]) set(ctx context.Context, level int, k K, v V, ttl time.Duration) {
+	if err := m.levels[level].Cache.Set(ctx, k, v, ttl); err == nil {
+		m.levelStats[level].Sets.Inc()
+	}
+}
+
+// delete removes the item from an upper level, ignoring its failure
+func (m *MultiLevel[K, V]) delete(ctx context.Context, level int, k K) {
+	if err := m.levels[level].Cache.Delete(ctx, k); err == nil {
+		m.levelStats[level].Deletes.Inc()
+	}
+}
+
+// deleteMany removes the items from an upper level, ignoring their failures
+func (m *MultiLevel[K, V]) deleteMany(ctx context.Context, level int, keys []K) {
+	errs := DeleteMany(ctx, m.levels[level].Cache, keys)
+	m.levelStats[level].Deletes.Add(len(keys) - len(errs))
+}
+
+// bound returns the ttl of an item written now to an upper level, so that it does not outlive the one of the level below
+// expiring at the deadline, together with the deadline of the upper level.
+// A zero deadline means that the item never expires, and it returns false when the item of the level below already expired
+func (m *MultiLevel[K, V]) bound(ttl time.Duration, deadline time.Time) (time.Duration, time.Time, bool) {
+	now := m.clock.Now()
+	if !deadline.IsZero() {
+		left := deadline.Sub(now)
+		if left <= 0 {
+			return 0, deadline, false
+		}
+
+		if ttl == NoExpiration || ttl > left {
+			ttl = left
+		}
+	}
+	return ttl, expiry(now, ttl), true
+}
+
+// ttl returns the ttl of the given level, replacing DefaultMultiLevelExpiration with its default one
+func (m *MultiLevel[K, V]) ttl(level int, ttl time.Duration) time.Duration {
+	if ttl == DefaultMultiLevelExpiration {
+		return m.levels[level].DefaultTTL
+	}
+	return ttl
+}
+
+// expiry returns when an item written at the given time with the given ttl expires, the zero time meaning never
+func expiry(at time.Time, ttl time.Duration) time.Time {
+	if ttl == NoExpiration {
+		return time.Time{}
+	}
+	return at.Add(ttl)
+}
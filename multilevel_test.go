@@ -1,16 +1,20 @@
-package cache
+package cache_test
 
 import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	. "github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/cachetest"
 )
 
 func TestMultiLevel(t *testing.T) {
 	t.Run("not found", func(t *testing.T) {
-		multiLvl := newMultiLevel(t)
+		multiLvl := newMultiLevel(t, cachetest.NewClock(time.Now()))
 
 		val, err := multiLvl.Get(context.Background(), "one")
 		if !errors.Is(err, ErrNotGet) {
@@ -22,8 +26,22 @@ func TestMultiLevel(t *testing.T) {
 		}
 	})
 
+	t.Run("reject missing levels", func(t *testing.T) {
+		levels := map[string][]Level[string, string]{
+			"nil":       nil,
+			"empty":     {},
+			"nil cache": {{DefaultTTL: time.Minute}},
+		}
+
+		for name, l := range levels {
+			if _, err := NewMultiLevel[string, string](l); !errors.Is(err, ErrInvalidLevels) {
+				t.Errorf("could not match invalid levels error on %s levels. got: %s", name, err)
+			}
+		}
+	})
+
 	t.Run("find set value", func(t *testing.T) {
-		multiLvl := newMultiLevel(t)
+		multiLvl := newMultiLevel(t, cachetest.NewClock(time.Now()))
 
 		const k = "key"
 		want := "value"
@@ -42,7 +60,7 @@ func TestMultiLevel(t *testing.T) {
 	})
 
 	t.Run("delete set value", func(t *testing.T) {
-		multiLvl := newMultiLevel(t)
+		multiLvl := newMultiLevel(t, cachetest.NewClock(time.Now()))
 
 		const k = "key"
 		want := "value"
@@ -65,7 +83,7 @@ func TestMultiLevel(t *testing.T) {
 	})
 
 	t.Run("concurrent set, get, and delete", func(t *testing.T) {
-		multiLvl := newMultiLevel(t)
+		multiLvl := newMultiLevel(t, cachetest.NewClock(time.Now()))
 
 		const c = 100
 		wg := sync.WaitGroup{}
@@ -84,7 +102,8 @@ func TestMultiLevel(t *testing.T) {
 	})
 
 	t.Run("get expired value", func(t *testing.T) {
-		multiLvl := newMultiLevel(t)
+		clock := cachetest.NewClock(time.Now())
+		multiLvl := newMultiLevel(t, clock)
 
 		const k = "key"
 		want := "value"
@@ -92,7 +111,7 @@ func TestMultiLevel(t *testing.T) {
 			t.Fatalf("could not set item: %s", err)
 		}
 
-		time.Sleep(time.Millisecond)
+		clock.Advance(2 * time.Millisecond)
 		val, err := multiLvl.Get(context.Background(), k)
 		if !errors.Is(err, ErrNotGet) {
 			t.Errorf("could not match not found error. got: %s", err)
@@ -102,20 +121,439 @@ func TestMultiLevel(t *testing.T) {
 			t.Errorf("could not match default value, got: %s", val)
 		}
 	})
-}
 
-func newMultiLevel(t *testing.T) *MultiLevel[string, string] {
-	t.Helper()
-	inmem1 := NewInMemory[string, string](time.Second, 5)
-	inmem2 := NewInMemory[string, string](time.Second, 5)
-	multiLlv := NewMultiLevel[string, string](inmem1, 10*time.Second, inmem2, 10*time.Second)
-	t.Cleanup(func() {
-		if err := inmem1.Close(); err != nil {
-			t.Errorf("could not close inmem1 level: %s", err)
+	t.Run("ensure local item does not outlive remote one", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		local := NewInMemory[string, string](time.Second, 5, ClockOption[string, string](clock))
+		inmem := NewInMemory[string, string](time.Second, 5, ClockOption[string, string](clock))
+		t.Cleanup(func() {
+			_ = local.Close()
+			_ = inmem.Close()
+		})
+		remote := slowCache{Cache: inmem, clock: clock, delay: time.Second}
+		multiLvl := newMultiLevelHelper(
+			t,
+			[]Level[string, string]{{Cache: local, DefaultTTL: time.Minute}, {Cache: remote, DefaultTTL: time.Minute}},
+			MultiLevelClockOption[string, string](clock),
+		)
+
+		const k = "key"
+		if err := multiLvl.Set(context.Background(), k, "value", time.Second); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if _, err := local.Get(context.Background(), k); !errors.Is(err, ErrNotFound) {
+			t.Errorf("could not match not found error. got: %s", err)
+		}
+	})
+
+	t.Run("remove stale local item when remote write consumes the ttl", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		local := NewInMemory[string, string](time.Second, 5, ClockOption[string, string](clock))
+		inmem := NewInMemory[string, string](time.Second, 5, ClockOption[string, string](clock))
+		t.Cleanup(func() {
+			_ = local.Close()
+			_ = inmem.Close()
+		})
+		remote := slowCache{Cache: inmem, clock: clock, delay: time.Second}
+		multiLvl := newMultiLevelHelper(
+			t,
+			[]Level[string, string]{{Cache: local, DefaultTTL: time.Minute}, {Cache: remote, DefaultTTL: time.Minute}},
+			MultiLevelClockOption[string, string](clock),
+		)
+
+		const k = "key"
+		if err := local.Set(context.Background(), k, "old", NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if err := multiLvl.Set(context.Background(), k, "new", time.Second); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if got, err := local.Get(context.Background(), k); !errors.Is(err, ErrNotFound) {
+			t.Errorf("could not match not found error. got: %s %v", got, err)
+		}
+	})
+
+	t.Run("use the default ttl of each level", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 3)
+		levels[0].DefaultTTL = time.Second
+		levels[1].DefaultTTL = time.Minute
+		levels[2].DefaultTTL = time.Hour
+		multiLvl := newMultiLevelHelper(t, levels, MultiLevelClockOption[string, string](clock))
+
+		const k = "key"
+		if err := multiLvl.Set(context.Background(), k, "value", DefaultMultiLevelExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		clock.Advance(2 * time.Second)
+		if _, err := levels[0].Cache.Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error on level 0. got: %s", err)
+		}
+		if _, err := levels[1].Cache.Get(context.Background(), k); err != nil {
+			t.Errorf("could not get item from level 1: %s", err)
+		}
+
+		clock.Advance(2 * time.Minute)
+		if _, err := levels[1].Cache.Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error on level 1. got: %s", err)
+		}
+		if _, err := multiLvl.Get(context.Background(), k); err != nil {
+			t.Errorf("could not get item from the last level: %s", err)
+		}
+	})
+
+	t.Run("ensure an item does not outlive the level below", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 3)
+		levels[0].DefaultTTL = time.Hour
+		levels[1].DefaultTTL = time.Minute
+		levels[2].DefaultTTL = time.Hour
+		multiLvl := newMultiLevelHelper(t, levels, MultiLevelClockOption[string, string](clock))
+
+		const k = "key"
+		if err := multiLvl.Set(context.Background(), k, "value", DefaultMultiLevelExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		clock.Advance(2 * time.Minute)
+		if _, err := levels[0].Cache.Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error on level 0. got: %s", err)
+		}
+	})
+
+	t.Run("read the levels in order", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 3)
+		multiLvl := newMultiLevelHelper(t, levels, MultiLevelClockOption[string, string](clock))
+
+		const k = "key"
+		if err := levels[2].Cache.Set(context.Background(), k, "last", NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+		if err := levels[1].Cache.Set(context.Background(), k, "middle", NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		got, err := multiLvl.Get(context.Background(), k)
+		if err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+		if got != "middle" {
+			t.Errorf("could not match value, got: %s. want: middle", got)
+		}
+	})
+
+	t.Run("write invalidate", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 3)
+		multiLvl := newMultiLevelHelper(
+			t,
+			levels,
+			MultiLevelClockOption[string, string](clock),
+			WriteStrategyOption[string, string](WriteInvalidate),
+		)
+
+		const k = "key"
+		for _, l := range levels {
+			if err := l.Cache.Set(context.Background(), k, "old", NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		if err := multiLvl.Set(context.Background(), k, "new", NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		for i, l := range levels[:2] {
+			if _, err := l.Cache.Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+				t.Errorf("could not match not found error on level %d. got: %s", i, err)
+			}
+		}
+
+		got, err := multiLvl.Get(context.Background(), k)
+		if err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+		if got != "new" {
+			t.Errorf("could not match value, got: %s. want: new", got)
+		}
+	})
+
+	t.Run("return the error of the last level", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 2)
+		levels[1].Cache = failingCache{}
+		multiLvl := newMultiLevelHelper(t, levels, MultiLevelClockOption[string, string](clock))
+
+		const k = "key"
+		if err := multiLvl.Set(context.Background(), k, "value", NoExpiration); !errors.Is(err, ErrNotSet) {
+			t.Errorf("could not match not set error. got: %s", err)
+		}
+		if _, err := levels[0].Cache.Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error on level 0. got: %s", err)
+		}
+		if err := multiLvl.Delete(context.Background(), k); !errors.Is(err, ErrNotDelete) {
+			t.Errorf("could not match not delete error. got: %s", err)
+		}
+	})
+
+	t.Run("backfill upper levels with their default ttl", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 3)
+		levels[0].DefaultTTL = time.Second
+		multiLvl := newMultiLevelHelper(
+			t,
+			levels,
+			MultiLevelClockOption[string, string](clock),
+			BackfillOption[string, string](BackfillDefaultTTL),
+		)
+
+		const k = "key"
+		if err := levels[2].Cache.Set(context.Background(), k, "value", time.Hour); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if _, err := multiLvl.Get(context.Background(), k); err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+		if err := multiLvl.Close(); err != nil {
+			t.Fatalf("could not close multi level: %s", err)
+		}
+
+		for i, l := range levels[:2] {
+			if got, err := l.Cache.Get(context.Background(), k); err != nil || got != "value" {
+				t.Errorf("could not get backfilled item from level %d, got: %s %v", i, got, err)
+			}
+		}
+
+		clock.Advance(2 * time.Second)
+		if _, err := levels[0].Cache.Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error on level 0. got: %s", err)
+		}
+	})
+
+	t.Run("backfill upper levels with the remaining ttl", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 2)
+		levels[0].DefaultTTL = time.Hour
+		multiLvl := newMultiLevelHelper(
+			t,
+			levels,
+			MultiLevelClockOption[string, string](clock),
+			BackfillOption[string, string](BackfillRemainingTTL),
+		)
+
+		const k = "key"
+		if err := levels[1].Cache.Set(context.Background(), k, "value", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if _, err := multiLvl.Get(context.Background(), k); err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+		if err := multiLvl.Close(); err != nil {
+			t.Fatalf("could not close multi level: %s", err)
+		}
+
+		if _, err := levels[0].Cache.Get(context.Background(), k); err != nil {
+			t.Errorf("could not get backfilled item: %s", err)
+		}
+
+		clock.Advance(2 * time.Minute)
+		if _, err := levels[0].Cache.Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error on level 0. got: %s", err)
+		}
+	})
+
+	t.Run("do not backfill an item written while backfilling", func(t *testing.T) {
+		writes := map[string]func(m *MultiLevel[string, string], k string) error{
+			"set": func(m *MultiLevel[string, string], k string) error {
+				return m.Set(context.Background(), k, "new", NoExpiration)
+			},
+			"delete": func(m *MultiLevel[string, string], k string) error {
+				return m.Delete(context.Background(), k)
+			},
+		}
+
+		for name, write := range writes {
+			clock := cachetest.NewClock(time.Now())
+			levels := newLevels(t, clock, 2)
+			upper := &blockingCache{Cache: levels[0].Cache, started: make(chan struct{}), release: make(chan struct{})}
+			levels[0].Cache = upper
+			multiLvl := newMultiLevelHelper(
+				t,
+				levels,
+				MultiLevelClockOption[string, string](clock),
+				BackfillOption[string, string](BackfillDefaultTTL),
+			)
+
+			const k = "key"
+			if err := levels[1].Cache.Set(context.Background(), k, "old", NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+
+			if _, err := multiLvl.Get(context.Background(), k); err != nil {
+				t.Fatalf("could not get item: %s", err)
+			}
+
+			<-upper.started
+			if err := write(multiLvl, k); err != nil {
+				t.Fatalf("could not %s item: %s", name, err)
+			}
+			close(upper.release)
+			if err := multiLvl.Close(); err != nil {
+				t.Fatalf("could not close multi level: %s", err)
+			}
+
+			if got, _ := levels[0].Cache.Get(context.Background(), k); got == "old" {
+				t.Errorf("could not remove stale backfilled item after %s", name)
+			}
+		}
+	})
+
+	t.Run("do not backfill by default", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 2)
+		multiLvl := newMultiLevelHelper(t, levels, MultiLevelClockOption[string, string](clock))
+
+		const k = "key"
+		if err := levels[1].Cache.Set(context.Background(), k, "value", NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if _, err := multiLvl.Get(context.Background(), k); err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+		_ = multiLvl.Close()
+
+		if _, err := levels[0].Cache.Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error on level 0. got: %s", err)
+		}
+	})
+
+	t.Run("invalidate upper levels of the other instances", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		bus := cachetest.NewInvalidationBus[string]()
+		shared := newLevels(t, clock, 1)[0]
+
+		replicas := make([]*MultiLevel[string, string], 2)
+		locals := make([]Cache[string, string], 2)
+		for i := range replicas {
+			local := newLevels(t, clock, 1)[0]
+			locals[i] = local.Cache
+			replicas[i] = newMultiLevelHelper(
+				t,
+				[]Level[string, string]{local, shared},
+				MultiLevelClockOption[string, string](clock),
+				InvalidationBusOption[string, string](bus),
+			)
+		}
+
+		const k = "key"
+		for _, r := range replicas {
+			if err := r.Set(context.Background(), k, "old", NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		if err := replicas[0].Set(context.Background(), k, "new", NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
 		}
-		if err := inmem2.Close(); err != nil {
-			t.Errorf("could not close inmem2 level: %s", err)
+
+		if got, err := locals[0].Get(context.Background(), k); err != nil || got != "new" {
+			t.Errorf("could not match value of the publishing instance, got: %s %v", got, err)
+		}
+		if _, err := locals[1].Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error on the other instance. got: %s", err)
+		}
+		if got, err := replicas[1].Get(context.Background(), k); err != nil || got != "new" {
+			t.Errorf("could not match value of the other instance, got: %s %v", got, err)
+		}
+
+		if err := replicas[1].Close(); err != nil {
+			t.Fatalf("could not close multi level: %s", err)
+		}
+		if err := replicas[1].Set(context.Background(), k, "newer", NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+		if _, err := locals[0].Get(context.Background(), k); !errors.Is(err, ErrNotGet) {
+			t.Errorf("could not match not found error after a closed instance set. got: %s", err)
 		}
 	})
-	return multiLlv
+}
+
+// failingCache is a Cache failing every operation
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) (string, error) {
+	return "", ErrNotGet
+}
+
+func (failingCache) Set(context.Context, string, string, time.Duration) error {
+	return ErrNotSet
+}
+
+func (failingCache) Delete(context.Context, string) error {
+	return ErrNotDelete
+}
+
+// blockingCache is a Cache whose first Set waits to be released, after telling that it started
+type blockingCache struct {
+	Cache[string, string]
+	blocked int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingCache) Set(ctx context.Context, k string, v string, ttl time.Duration) error {
+	if atomic.CompareAndSwapInt32(&b.blocked, 0, 1) {
+		close(b.started)
+		<-b.release
+	}
+	return b.Cache.Set(ctx, k, v, ttl)
+}
+
+// slowCache is a Cache advancing the clock by a delay on every Set
+type slowCache struct {
+	Cache[string, string]
+	clock *cachetest.Clock
+	delay time.Duration
+}
+
+func (s slowCache) Set(ctx context.Context, k string, v string, ttl time.Duration) error {
+	s.clock.Advance(s.delay)
+	return s.Cache.Set(ctx, k, v, ttl)
+}
+
+func newMultiLevel(t *testing.T, clock Clock) *MultiLevel[string, string] {
+	t.Helper()
+	return newMultiLevelHelper(t, newLevels(t, clock, 2), MultiLevelClockOption[string, string](clock))
+}
+
+func newMultiLevelHelper(t *testing.T, levels []Level[string, string], opts ...MultiLevelOption[string, string]) *MultiLevel[string, string] {
+	t.Helper()
+	m, err := NewMultiLevel[string, string](levels, opts...)
+	if err != nil {
+		t.Fatalf("could not create multi level: %s", err)
+	}
+	return m
+}
+
+func newLevels(t *testing.T, clock Clock, n int) []Level[string, string] {
+	t.Helper()
+	levels := make([]Level[string, string], n)
+	for i := range levels {
+		inmem := NewInMemory[string, string](time.Second, 5, ClockOption[string, string](clock))
+		t.Cleanup(func() {
+			if err := inmem.Close(); err != nil {
+				t.Errorf("could not close inmem level: %s", err)
+			}
+		})
+		levels[i] = Level[string, string]{Cache: inmem, DefaultTTL: 10 * time.Second}
+	}
+	return levels
 }
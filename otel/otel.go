@@ -0,0 +1,281 @@
+// Package otel traces the operations of the caches and records their metrics with OpenTelemetry
+package otel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/damianopetrungaro/go-cache"
+)
+
+var (
+	_ cache.Cache[string, any]      = &Cache[string, any]{}
+	_ cache.BatchCache[string, any] = &Cache[string, any]{}
+)
+
+// instrumentationName is the name of the tracer and the meter
+const instrumentationName = "github.com/damianopetrungaro/go-cache/otel"
+
+// List of attributes set to the spans and the metrics
+const (
+	BackendKey   = attribute.Key("cache.backend")
+	NameKey      = attribute.Key("cache.name")
+	OperationKey = attribute.Key("cache.operation")
+	HitKey       = attribute.Key("cache.hit")
+	TTLKey       = attribute.Key("cache.ttl_ms")
+	KeyHashKey   = attribute.Key("cache.key_hash")
+	KeysKey      = attribute.Key("cache.keys")
+	HitsKey      = attribute.Key("cache.hits")
+	ErrorKey     = attribute.Key("cache.error")
+)
+
+// List of operations used as span names and attribute values
+const (
+	opGet        = "cache.get"
+	opSet        = "cache.set"
+	opDelete     = "cache.delete"
+	opGetMany    = "cache.get_many"
+	opSetMany    = "cache.set_many"
+	opDeleteMany = "cache.delete_many"
+)
+
+// Option represents a function which applies changes to a Cache instance
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// TracerProviderOption represents an Option which creates the spans with the given trace.TracerProvider
+// It defaults to the global one
+func TracerProviderOption[K comparable, V any](tp trace.TracerProvider) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.tp = tp
+	}
+}
+
+// MeterProviderOption represents an Option which records the metrics with the given metric.MeterProvider
+// It defaults to the global one
+func MeterProviderOption[K comparable, V any](mp metric.MeterProvider) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.mp = mp
+	}
+}
+
+// BackendOption represents an Option which sets the backend attribute, it defaults to the type name of the wrapped cache
+func BackendOption[K comparable, V any](backend string) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.backend = backend
+	}
+}
+
+// NameOption represents an Option which sets the name attribute, telling apart many caches of the same backend
+func NameOption[K comparable, V any](name string) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.name = name
+	}
+}
+
+// KeyHashOption represents an Option which redacts the keys set as span attribute with the given function.
+// It defaults to cache.RedactKey. A nil function leaves the key out of the spans
+func KeyHashOption[K comparable, V any](hash func(K) string) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.keyHash = hash
+	}
+}
+
+// Cache is a cache.Cache implementation which wraps a cache.Cache to trace its operations with OpenTelemetry.
+// Every operation creates a span, carrying the backend, the redacted key, the ttl and whether it was a hit,
+// and records its duration, its hits and misses and its errors as metrics.
+// A miss is not an error, so it is recorded as a span attribute only
+// It is concurrent safe
+type Cache[K comparable, V any] struct {
+	cache    cache.Cache[K, V]
+	tp       trace.TracerProvider
+	mp       metric.MeterProvider
+	backend  string
+	name     string
+	keyHash  func(K) string
+	tracer   trace.Tracer
+	attrs    []attribute.KeyValue
+	attrSet  metric.MeasurementOption
+	duration metric.Float64Histogram
+	hits     metric.Int64Counter
+	misses   metric.Int64Counter
+	errors   metric.Int64Counter
+}
+
+// New returns a Cache wrapping the given cache.Cache, it fails when the metric instruments cannot be created
+func New[K comparable, V any](c cache.Cache[K, V], opts ...Option[K, V]) (*Cache[K, V], error) {
+	t := &Cache[K, V]{
+		cache:   c,
+		tp:      otel.GetTracerProvider(),
+		mp:      otel.GetMeterProvider(),
+		backend: backendOf(c),
+		keyHash: cache.RedactKey[K],
+	}
+
+	for _, o := range opts {
+		o(t)
+	}
+
+	t.tracer = t.tp.Tracer(instrumentationName)
+	t.attrs = []attribute.KeyValue{BackendKey.String(t.backend)}
+	if t.name != "" {
+		t.attrs = append(t.attrs, NameKey.String(t.name))
+	}
+	t.attrSet = metric.WithAttributeSet(attribute.NewSet(t.attrs...))
+
+	meter := t.mp.Meter(instrumentationName)
+	var err error
+	if t.duration, err = meter.Float64Histogram(
+		"cache.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of the cache operations."),
+	); err != nil {
+		return nil, fmt.Errorf("could not create duration histogram: %w", err)
+	}
+
+	if t.hits, err = meter.Int64Counter("cache.hits", metric.WithDescription("Reads which found the item.")); err != nil {
+		return nil, fmt.Errorf("could not create hits counter: %w", err)
+	}
+
+	if t.misses, err = meter.Int64Counter("cache.misses", metric.WithDescription("Reads which could not find the item.")); err != nil {
+		return nil, fmt.Errorf("could not create misses counter: %w", err)
+	}
+
+	if t.errors, err = meter.Int64Counter("cache.errors", metric.WithDescription("Failed cache operations by error, misses excluded.")); err != nil {
+		return nil, fmt.Errorf("could not create errors counter: %w", err)
+	}
+
+	return t, nil
+}
+
+// Get retrieves an item from the wrapped cache within a span
+func (c *Cache[K, V]) Get(ctx context.Context, k K) (V, error) {
+	ctx, span, start := c.start(ctx, opGet, c.keyAttrs(k)...)
+	val, err := c.cache.Get(ctx, k)
+
+	span.SetAttributes(HitKey.Bool(err == nil))
+	failed := err
+	switch {
+	case err == nil:
+		c.hits.Add(ctx, 1, c.attrSet)
+	case cache.ClassifyError(err) == cache.ErrorClassMiss:
+		c.misses.Add(ctx, 1, c.attrSet)
+		failed = nil
+	}
+	c.end(ctx, span, start, opGet, failed)
+
+	return val, err
+}
+
+// Set stores an item to the wrapped cache within a span
+func (c *Cache[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
+	ctx, span, start := c.start(ctx, opSet, append(c.keyAttrs(k), TTLKey.Int64(ttl.Milliseconds()))...)
+	err := c.cache.Set(ctx, k, v, ttl)
+	c.end(ctx, span, start, opSet, err)
+	return err
+}
+
+// Delete removes an item from the wrapped cache within a span
+func (c *Cache[K, V]) Delete(ctx context.Context, k K) error {
+	ctx, span, start := c.start(ctx, opDelete, c.keyAttrs(k)...)
+	err := c.cache.Delete(ctx, k)
+	c.end(ctx, span, start, opDelete, err)
+	return err
+}
+
+// GetMany retrieves many items from the wrapped cache within a single span, carrying the number of keys and of hits
+func (c *Cache[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, map[K]error) {
+	ctx, span, start := c.start(ctx, opGetMany, KeysKey.Int(len(keys)))
+	vals, errs := cache.GetMany(ctx, c.cache, keys)
+
+	span.SetAttributes(HitsKey.Int(len(vals)))
+	c.hits.Add(ctx, int64(len(vals)), c.attrSet)
+	var failed error
+	for _, err := range errs {
+		switch {
+		case cache.ClassifyError(err) == cache.ErrorClassMiss:
+			c.misses.Add(ctx, 1, c.attrSet)
+		case failed == nil:
+			failed = err
+		}
+	}
+	c.end(ctx, span, start, opGetMany, failed)
+
+	return vals, errs
+}
+
+// SetMany stores many items to the wrapped cache within a single span, carrying the number of keys
+func (c *Cache[K, V]) SetMany(ctx context.Context, items map[K]V, ttl time.Duration) map[K]error {
+	ctx, span, start := c.start(ctx, opSetMany, KeysKey.Int(len(items)), TTLKey.Int64(ttl.Milliseconds()))
+	errs := cache.SetMany(ctx, c.cache, items, ttl)
+	c.end(ctx, span, start, opSetMany, anyError(errs))
+	return errs
+}
+
+// DeleteMany removes many items from the wrapped cache within a single span, carrying the number of keys
+func (c *Cache[K, V]) DeleteMany(ctx context.Context, keys []K) map[K]error {
+	ctx, span, start := c.start(ctx, opDeleteMany, KeysKey.Int(len(keys)))
+	errs := cache.DeleteMany(ctx, c.cache, keys)
+	c.end(ctx, span, start, opDeleteMany, anyError(errs))
+	return errs
+}
+
+// start starts the span of an operation
+func (c *Cache[K, V]) start(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, trace.Span, time.Time) {
+	ctx, span := c.tracer.Start(ctx, op, trace.WithAttributes(c.attrs...), trace.WithAttributes(attrs...))
+	return ctx, span, time.Now()
+}
+
+// end records the duration and the error of an operation, then ends its span
+func (c *Cache[K, V]) end(ctx context.Context, span trace.Span, start time.Time, op string, err error) {
+	attrs := append([]attribute.KeyValue{OperationKey.String(op)}, c.attrs...)
+	c.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.errors.Add(ctx, 1, metric.WithAttributes(append(attrs, ErrorKey.String(cache.ClassifyError(err).String()))...))
+	}
+	span.End()
+}
+
+// keyAttrs returns the attributes of the redacted key, if any
+func (c *Cache[K, V]) keyAttrs(k K) []attribute.KeyValue {
+	if c.keyHash == nil {
+		return nil
+	}
+	return []attribute.KeyValue{KeyHashKey.String(c.keyHash(k))}
+}
+
+// backendOf returns the type name of the cache without its package and type parameters, such as InMem or Redis
+func backendOf(c any) string {
+	t := reflect.TypeOf(c)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "unknown"
+	}
+
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// anyError returns one of the errors, nil when there are none
+func anyError[K comparable](errs map[K]error) error {
+	for _, err := range errs {
+		return err
+	}
+	return nil
+}
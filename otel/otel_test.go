@@ -0,0 +1,226 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/damianopetrungaro/go-cache"
+	. "github.com/damianopetrungaro/go-cache/otel"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("trace operations", func(t *testing.T) {
+		c, exporter := newCacheHelper(t, cache.NewInMemory[string, string](time.Minute, 10))
+
+		_ = c.Set(context.Background(), "user@example.com", "value", time.Minute)
+		_, _ = c.Get(context.Background(), "user@example.com")
+		_, _ = c.Get(context.Background(), "missing")
+		_ = c.Delete(context.Background(), "user@example.com")
+
+		spans := exporter.GetSpans()
+		if len(spans) != 4 {
+			t.Fatalf("could not match spans, got: %d", len(spans))
+		}
+
+		wantNames := []string{"cache.set", "cache.get", "cache.get", "cache.delete"}
+		for i, span := range spans {
+			if span.Name != wantNames[i] {
+				t.Errorf("could not match span name, got: %s. want: %s", span.Name, wantNames[i])
+			}
+
+			attrs := attrsOf(span)
+			if v, _ := attrs.Value(BackendKey); v.AsString() != "InMem" {
+				t.Errorf("could not match backend, got: %s", v.AsString())
+			}
+
+			hash, ok := attrs.Value(KeyHashKey)
+			if !ok || len(hash.AsString()) != 16 {
+				t.Errorf("could not match key hash, got: %s", hash.AsString())
+			}
+
+			for _, kv := range span.Attributes {
+				if kv.Value.AsString() == "user@example.com" {
+					t.Errorf("could not redact key in attribute %s", kv.Key)
+				}
+			}
+
+			if span.Status.Code == codes.Error {
+				t.Errorf("could not match status of %s, got: %s", span.Name, span.Status.Description)
+			}
+		}
+
+		if v, _ := attrsOf(spans[0]).Value(TTLKey); v.AsInt64() != time.Minute.Milliseconds() {
+			t.Errorf("could not match ttl, got: %d", v.AsInt64())
+		}
+
+		if v, _ := attrsOf(spans[1]).Value(HitKey); !v.AsBool() {
+			t.Error("could not match hit")
+		}
+
+		if v, _ := attrsOf(spans[2]).Value(HitKey); v.AsBool() {
+			t.Error("could not match miss")
+		}
+	})
+
+	t.Run("propagate the span context", func(t *testing.T) {
+		var got context.Context
+		c, exporter := newCacheHelper(t, ctxCache{ctx: &got})
+
+		_, _ = c.Get(context.Background(), "key")
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("could not match spans, got: %d", len(spans))
+		}
+
+		if got == nil || !trace.SpanContextFromContext(got).Equal(spans[0].SpanContext) {
+			t.Error("could not match span context passed to the wrapped cache")
+		}
+	})
+
+	t.Run("record errors", func(t *testing.T) {
+		c, exporter := newCacheHelper(t, failingCache{})
+
+		_, err := c.Get(context.Background(), "key")
+		if !errors.Is(err, cache.ErrNotGet) {
+			t.Errorf("could not match returned error, got: %v", err)
+		}
+		_ = c.Set(context.Background(), "key", "value", time.Minute)
+
+		for _, span := range exporter.GetSpans() {
+			if span.Status.Code != codes.Error {
+				t.Errorf("could not match error status of %s", span.Name)
+			}
+
+			if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+				t.Errorf("could not match error event of %s, got: %v", span.Name, span.Events)
+			}
+		}
+	})
+
+	t.Run("leave keys out", func(t *testing.T) {
+		c, exporter := newCacheHelper(
+			t,
+			cache.NewInMemory[string, string](time.Minute, 10),
+			KeyHashOption[string, string](nil),
+			BackendOption[string, string]("local"),
+		)
+
+		_, _ = c.Get(context.Background(), "key")
+
+		attrs := attrsOf(exporter.GetSpans()[0])
+		if _, ok := attrs.Value(KeyHashKey); ok {
+			t.Error("could not leave key out")
+		}
+
+		if v, _ := attrs.Value(BackendKey); v.AsString() != "local" {
+			t.Errorf("could not match backend, got: %s", v.AsString())
+		}
+	})
+
+	t.Run("emit metrics", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := MeterProviderOption[string, string](sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+		c, _ := newCacheHelper(t, cache.NewInMemory[string, string](time.Minute, 10), mp)
+		failing, _ := newCacheHelper(t, failingCache{}, mp)
+
+		_ = c.Set(context.Background(), "one", "1", time.Minute)
+		_, _ = c.Get(context.Background(), "one")
+		_, _ = c.GetMany(context.Background(), []string{"one", "two", "three"})
+		_ = failing.Delete(context.Background(), "one")
+
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatalf("could not collect metrics: %s", err)
+		}
+
+		sums := map[string]int64{}
+		var durations uint64
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				switch data := m.Data.(type) {
+				case metricdata.Sum[int64]:
+					for _, dp := range data.DataPoints {
+						sums[m.Name] += dp.Value
+					}
+				case metricdata.Histogram[float64]:
+					for _, dp := range data.DataPoints {
+						durations += dp.Count
+					}
+				}
+			}
+		}
+
+		want := map[string]int64{"cache.hits": 2, "cache.misses": 2, "cache.errors": 1}
+		for name, n := range want {
+			if sums[name] != n {
+				t.Errorf("could not match %s, got: %d. want: %d", name, sums[name], n)
+			}
+		}
+
+		if durations != 4 {
+			t.Errorf("could not match recorded durations, got: %d", durations)
+		}
+	})
+}
+
+func newCacheHelper(
+	t *testing.T,
+	c cache.Cache[string, string],
+	opts ...Option[string, string],
+) (*Cache[string, string], *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	opts = append([]Option[string, string]{
+		TracerProviderOption[string, string](tp),
+		MeterProviderOption[string, string](sdkmetric.NewMeterProvider()),
+	}, opts...)
+
+	traced, err := New[string, string](c, opts...)
+	if err != nil {
+		t.Fatalf("could not create cache: %s", err)
+	}
+	return traced, exporter
+}
+
+func attrsOf(span tracetest.SpanStub) *attribute.Set {
+	attrs := attribute.NewSet(span.Attributes...)
+	return &attrs
+}
+
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) (string, error) {
+	return "", cache.ErrNotGet
+}
+
+func (failingCache) Set(context.Context, string, string, time.Duration) error {
+	return cache.ErrNotSet
+}
+
+func (failingCache) Delete(context.Context, string) error {
+	return cache.ErrNotDelete
+}
+
+// ctxCache records the context passed to Get
+type ctxCache struct {
+	failingCache
+	ctx *context.Context
+}
+
+func (c ctxCache) Get(ctx context.Context, _ string) (string, error) {
+	*c.ctx = ctx
+	return "", cache.ErrNotFound
+}
@@ -0,0 +1,68 @@
+package cache
+
+// policy tracks the items of an InMem to pick the one to evict when the max capacity gets hit
+type policy[K comparable, V any] interface {
+	// access records a read of the key, the item is nil when the key is not in the cache
+	access(K, *item[K, V])
+	insert(*item[K, V])
+	remove(*item[K, V])
+	// resize records the new cost of a tracked item, before it gets applied
+	resize(*item[K, V], int64)
+	// victim returns the item to evict, it may be the one just inserted
+	victim() *item[K, V]
+}
+
+// lru is a policy which evicts the least recently used item
+type lru[K comparable, V any] struct {
+	recent list[K, V]
+}
+
+func (l *lru[K, V]) access(_ K, it *item[K, V]) {
+	if it != nil {
+		l.recent.moveToFront(it)
+	}
+}
+
+func (l *lru[K, V]) insert(it *item[K, V]) {
+	l.recent.pushFront(it)
+}
+
+func (l *lru[K, V]) remove(it *item[K, V]) {
+	l.recent.remove(it)
+}
+
+func (l *lru[K, V]) resize(*item[K, V], int64) {}
+
+func (l *lru[K, V]) victim() *item[K, V] {
+	return l.recent.back()
+}
+
+// evictionPolicy adapts an EvictionPolicy, which tracks keys, to a policy
+type evictionPolicy[K comparable, V any] struct {
+	policy EvictionPolicy[K]
+	inmem  *InMem[K, V]
+}
+
+func (e *evictionPolicy[K, V]) access(k K, it *item[K, V]) {
+	if it != nil {
+		e.policy.Access(k)
+	}
+}
+
+func (e *evictionPolicy[K, V]) insert(it *item[K, V]) {
+	e.policy.Insert(it.key)
+}
+
+func (e *evictionPolicy[K, V]) remove(it *item[K, V]) {
+	e.policy.Remove(it.key)
+}
+
+func (e *evictionPolicy[K, V]) resize(*item[K, V], int64) {}
+
+func (e *evictionPolicy[K, V]) victim() *item[K, V] {
+	k, ok := e.policy.Victim()
+	if !ok {
+		return nil
+	}
+	return e.inmem.items[k]
+}
@@ -0,0 +1,261 @@
+// Package prometheus exports the metrics of the caches to Prometheus
+package prometheus
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/damianopetrungaro/go-cache"
+)
+
+var (
+	_ cache.Cache[string, any]      = &Cache[string, any]{}
+	_ cache.BatchCache[string, any] = &Cache[string, any]{}
+	_ prometheus.Collector          = &Cache[string, any]{}
+)
+
+// List of operations used as label of the metrics
+const (
+	opGet        = "get"
+	opSet        = "set"
+	opDelete     = "delete"
+	opGetMany    = "get_many"
+	opSetMany    = "set_many"
+	opDeleteMany = "delete_many"
+)
+
+// Option represents a function which applies changes to a Cache instance
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// NamespaceOption represents an Option which sets the namespace of the metrics, it defaults to "cache"
+func NamespaceOption[K comparable, V any](namespace string) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.namespace = namespace
+	}
+}
+
+// BucketsOption represents an Option which sets the buckets of the latency histograms, in seconds
+// They default to exponential buckets from 50µs to about 3s
+func BucketsOption[K comparable, V any](buckets []float64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.buckets = buckets
+	}
+}
+
+// Cache is a cache.Cache implementation which wraps a cache.Cache to export its metrics to Prometheus.
+// It measures the latency of every operation and counts the errors by sentinel, while the hits, misses,
+// evictions and item count are the ones of the wrapped cache when it is a cache.StatsReporter.
+// The metrics carry a name label, so that many caches can be registered to the same registry
+// It is concurrent safe
+type Cache[K comparable, V any] struct {
+	cache     cache.Cache[K, V]
+	name      string
+	namespace string
+	buckets   []float64
+	durations *prometheus.HistogramVec
+	errors    *prometheus.CounterVec
+	observers map[string]prometheus.Observer
+	stats     prometheus.Collector
+}
+
+// New returns a Cache wrapping the given cache.Cache, whose metrics are labeled with the given name
+// It must be registered to a prometheus.Registerer to export them
+func New[K comparable, V any](name string, c cache.Cache[K, V], opts ...Option[K, V]) *Cache[K, V] {
+	m := &Cache[K, V]{
+		cache:     c,
+		name:      name,
+		namespace: "cache",
+		buckets:   prometheus.ExponentialBuckets(0.00005, 4, 9),
+	}
+
+	for _, o := range opts {
+		o(m)
+	}
+
+	labels := prometheus.Labels{"name": name}
+	m.durations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   m.namespace,
+		Name:        "operation_duration_seconds",
+		Help:        "Latency of the cache operations.",
+		ConstLabels: labels,
+		Buckets:     m.buckets,
+	}, []string{"operation"})
+	m.errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   m.namespace,
+		Name:        "errors_total",
+		Help:        "Failed cache operations by error, misses excluded.",
+		ConstLabels: labels,
+	}, []string{"operation", "error"})
+
+	m.observers = map[string]prometheus.Observer{}
+	for _, op := range []string{opGet, opSet, opDelete, opGetMany, opSetMany, opDeleteMany} {
+		m.observers[op] = m.durations.WithLabelValues(op)
+	}
+
+	if r, ok := c.(cache.StatsReporter); ok {
+		m.stats = newStatsCollector(m.namespace, name, r)
+	}
+
+	return m
+}
+
+// Get retrieves an item from the wrapped cache
+func (c *Cache[K, V]) Get(ctx context.Context, k K) (V, error) {
+	defer c.observe(opGet, time.Now())
+	val, err := c.cache.Get(ctx, k)
+	c.count(opGet, err)
+	return val, err
+}
+
+// Set stores an item to the wrapped cache
+func (c *Cache[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
+	defer c.observe(opSet, time.Now())
+	err := c.cache.Set(ctx, k, v, ttl)
+	c.count(opSet, err)
+	return err
+}
+
+// Delete removes an item from the wrapped cache
+func (c *Cache[K, V]) Delete(ctx context.Context, k K) error {
+	defer c.observe(opDelete, time.Now())
+	err := c.cache.Delete(ctx, k)
+	c.count(opDelete, err)
+	return err
+}
+
+// GetMany retrieves many items from the wrapped cache
+func (c *Cache[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, map[K]error) {
+	defer c.observe(opGetMany, time.Now())
+	vals, errs := cache.GetMany(ctx, c.cache, keys)
+	c.countMany(opGetMany, errs)
+	return vals, errs
+}
+
+// SetMany stores many items to the wrapped cache
+func (c *Cache[K, V]) SetMany(ctx context.Context, items map[K]V, ttl time.Duration) map[K]error {
+	defer c.observe(opSetMany, time.Now())
+	errs := cache.SetMany(ctx, c.cache, items, ttl)
+	c.countMany(opSetMany, errs)
+	return errs
+}
+
+// DeleteMany removes many items from the wrapped cache
+func (c *Cache[K, V]) DeleteMany(ctx context.Context, keys []K) map[K]error {
+	defer c.observe(opDeleteMany, time.Now())
+	errs := cache.DeleteMany(ctx, c.cache, keys)
+	c.countMany(opDeleteMany, errs)
+	return errs
+}
+
+// Describe sends the descriptors of the metrics to the channel
+func (c *Cache[K, V]) Describe(ch chan<- *prometheus.Desc) {
+	c.durations.Describe(ch)
+	c.errors.Describe(ch)
+	if c.stats != nil {
+		c.stats.Describe(ch)
+	}
+}
+
+// Collect sends the metrics to the channel, reading the statistics of the wrapped cache
+func (c *Cache[K, V]) Collect(ch chan<- prometheus.Metric) {
+	c.durations.Collect(ch)
+	c.errors.Collect(ch)
+	if c.stats != nil {
+		c.stats.Collect(ch)
+	}
+}
+
+func (c *Cache[K, V]) observe(op string, start time.Time) {
+	c.observers[op].Observe(time.Since(start).Seconds())
+}
+
+func (c *Cache[K, V]) count(op string, err error) {
+	switch class := cache.ClassifyError(err); class {
+	case cache.ErrorClassNone, cache.ErrorClassMiss:
+	default:
+		c.errors.WithLabelValues(op, class.String()).Inc()
+	}
+}
+
+func (c *Cache[K, V]) countMany(op string, errs map[K]error) {
+	for _, err := range errs {
+		c.count(op, err)
+	}
+}
+
+// NewStatsCollector returns a prometheus.Collector exporting the statistics of a cache.StatsReporter with the given name label,
+// such as a cache.Loader, which are otherwise exported by a Cache wrapping it
+func NewStatsCollector(name string, r cache.StatsReporter) prometheus.Collector {
+	return newStatsCollector("cache", name, r)
+}
+
+// statsCollector exports the statistics of a cache.StatsReporter, reading them on every scrape
+type statsCollector struct {
+	reporter   cache.StatsReporter
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	expired    *prometheus.Desc
+	hitRatio   *prometheus.Desc
+	items      *prometheus.Desc
+	evictions  *prometheus.Desc
+	loadErrors *prometheus.Desc
+	levelHits  *prometheus.Desc
+	levelMiss  *prometheus.Desc
+}
+
+func newStatsCollector(namespace, name string, r cache.StatsReporter) *statsCollector {
+	labels := prometheus.Labels{"name": name}
+	desc := func(metric, help string, variable ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metric), help, variable, labels)
+	}
+
+	return &statsCollector{
+		reporter:   r,
+		hits:       desc("hits_total", "Reads which found the item."),
+		misses:     desc("misses_total", "Reads which could not find the item."),
+		expired:    desc("expired_total", "Reads which found the item expired."),
+		hitRatio:   desc("hit_ratio", "Ratio of the reads which found the item."),
+		items:      desc("items", "Number of items in the cache."),
+		evictions:  desc("evictions_total", "Items which left the cache, by reason.", "reason"),
+		loadErrors: desc("load_errors_total", "Loads which failed."),
+		levelHits:  desc("level_hits_total", "Reads which found the item, by level of a multi level cache.", "level"),
+		levelMiss:  desc("level_misses_total", "Reads which could not find the item, by level of a multi level cache.", "level"),
+	}
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.expired
+	ch <- c.hitRatio
+	ch <- c.items
+	ch <- c.evictions
+	ch <- c.loadErrors
+	ch <- c.levelHits
+	ch <- c.levelMiss
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.reporter.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(s.Expired))
+	ch <- prometheus.MustNewConstMetric(c.hitRatio, prometheus.GaugeValue, s.HitRatio())
+	ch <- prometheus.MustNewConstMetric(c.loadErrors, prometheus.CounterValue, float64(s.LoadErrors))
+	if s.Size >= 0 {
+		ch <- prometheus.MustNewConstMetric(c.items, prometheus.GaugeValue, float64(s.Size))
+	}
+
+	for reason, n := range s.Evictions {
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(n), reason.String())
+	}
+
+	for i, l := range s.Levels {
+		level := strconv.Itoa(i)
+		ch <- prometheus.MustNewConstMetric(c.levelHits, prometheus.CounterValue, float64(l.Hits), level)
+		ch <- prometheus.MustNewConstMetric(c.levelMiss, prometheus.CounterValue, float64(l.Misses), level)
+	}
+}
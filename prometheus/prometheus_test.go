@@ -0,0 +1,168 @@
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/damianopetrungaro/go-cache"
+	. "github.com/damianopetrungaro/go-cache/prometheus"
+)
+
+func TestCache(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	users := New[string, string]("users", cache.NewInMemory[string, string](time.Minute, 2, cache.LRUOption[string, string]()))
+	failing := New[string, string]("failing", failingCache{})
+	reg.MustRegister(users, failing)
+
+	ctx := context.Background()
+	_ = users.Set(ctx, "one", "1", time.Minute)
+	_ = users.Set(ctx, "two", "2", time.Minute)
+	_ = users.Set(ctx, "three", "3", time.Minute)
+	_, _ = users.Get(ctx, "three")
+	_, _ = users.Get(ctx, "one")
+	_, _ = users.GetMany(ctx, []string{"two", "three"})
+
+	_, _ = failing.Get(ctx, "one")
+	_ = failing.Set(ctx, "one", "1", time.Minute)
+	_ = failing.Delete(ctx, "one")
+	_ = failing.DeleteMany(ctx, []string{"one", "two"})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("could not gather metrics: %s", err)
+	}
+
+	tests := []struct {
+		metric string
+		labels map[string]string
+		want   float64
+	}{
+		{metric: "cache_hits_total", labels: map[string]string{"name": "users"}, want: 3},
+		{metric: "cache_misses_total", labels: map[string]string{"name": "users"}, want: 1},
+		{metric: "cache_hit_ratio", labels: map[string]string{"name": "users"}, want: 0.75},
+		{metric: "cache_items", labels: map[string]string{"name": "users"}, want: 2},
+		{metric: "cache_evictions_total", labels: map[string]string{"name": "users", "reason": "capacity"}, want: 1},
+		{metric: "cache_operation_duration_seconds", labels: map[string]string{"name": "users", "operation": "set"}, want: 3},
+		{metric: "cache_operation_duration_seconds", labels: map[string]string{"name": "users", "operation": "get_many"}, want: 1},
+		{metric: "cache_errors_total", labels: map[string]string{"name": "failing", "operation": "get", "error": "not_get"}, want: 1},
+		{metric: "cache_errors_total", labels: map[string]string{"name": "failing", "operation": "set", "error": "not_set"}, want: 1},
+		{metric: "cache_errors_total", labels: map[string]string{"name": "failing", "operation": "delete", "error": "not_delete"}, want: 1},
+		{metric: "cache_errors_total", labels: map[string]string{"name": "failing", "operation": "delete_many", "error": "not_delete"}, want: 2},
+	}
+
+	for _, tt := range tests {
+		got, ok := value(families, tt.metric, tt.labels)
+		if !ok {
+			t.Errorf("could not find metric %s %v", tt.metric, tt.labels)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("could not match metric %s %v, got: %f. want: %f", tt.metric, tt.labels, got, tt.want)
+		}
+	}
+
+	if _, ok := value(families, "cache_errors_total", map[string]string{"name": "users", "operation": "get"}); ok {
+		t.Error("could not exclude misses from errors")
+	}
+
+	if _, ok := value(families, "cache_hits_total", map[string]string{"name": "failing"}); ok {
+		t.Error("could not skip the statistics of a cache not reporting them")
+	}
+}
+
+func TestStatsCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	levels := []cache.Level[string, string]{
+		{Cache: cache.NewInMemory[string, string](time.Minute, 10), DefaultTTL: time.Minute},
+		{Cache: cache.NewInMemory[string, string](time.Minute, 10), DefaultTTL: time.Minute},
+	}
+	multiLvl, err := cache.NewMultiLevel[string, string](levels)
+	if err != nil {
+		t.Fatalf("could not create multi level: %s", err)
+	}
+	loader := cache.NewLoader[string, string](multiLvl)
+	reg.MustRegister(NewStatsCollector("products", loader))
+
+	ctx := context.Background()
+	_ = multiLvl.Set(ctx, "one", "1", time.Minute)
+	_, _ = loader.GetOrLoad(ctx, "one", nil)
+	_, _ = loader.GetOrLoad(ctx, "two", func(context.Context) (string, time.Duration, error) {
+		return "", 0, errors.New("load failure")
+	})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("could not gather metrics: %s", err)
+	}
+
+	tests := []struct {
+		metric string
+		labels map[string]string
+		want   float64
+	}{
+		{metric: "cache_load_errors_total", labels: map[string]string{"name": "products"}, want: 1},
+		{metric: "cache_level_hits_total", labels: map[string]string{"name": "products", "level": "0"}, want: 1},
+		{metric: "cache_level_misses_total", labels: map[string]string{"name": "products", "level": "1"}, want: 1},
+	}
+
+	for _, tt := range tests {
+		got, ok := value(families, tt.metric, tt.labels)
+		if !ok || got != tt.want {
+			t.Errorf("could not match metric %s %v, got: %f %t. want: %f", tt.metric, tt.labels, got, ok, tt.want)
+		}
+	}
+}
+
+// value returns the value of the metric having the given labels, the sample count for histograms
+func value(families []*dto.MetricFamily, name string, labels map[string]string) (float64, bool) {
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+
+	metrics:
+		for _, m := range f.GetMetric() {
+			got := map[string]string{}
+			for _, l := range m.GetLabel() {
+				got[l.GetName()] = l.GetValue()
+			}
+			for k, v := range labels {
+				if got[k] != v {
+					continue metrics
+				}
+			}
+
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				return m.GetCounter().GetValue(), true
+			case dto.MetricType_GAUGE:
+				return m.GetGauge().GetValue(), true
+			case dto.MetricType_HISTOGRAM:
+				return float64(m.GetHistogram().GetSampleCount()), true
+			}
+		}
+	}
+	return 0, false
+}
+
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) (string, error) {
+	return "", cache.ErrNotGet
+}
+
+func (failingCache) Set(context.Context, string, string, time.Duration) error {
+	return cache.ErrNotSet
+}
+
+func (failingCache) Delete(context.Context, string) error {
+	return cache.ErrNotDelete
+}
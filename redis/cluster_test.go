@@ -0,0 +1,164 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/damianopetrungaro/go-cache"
+	. "github.com/damianopetrungaro/go-cache/redis"
+)
+
+func TestRedisCluster(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test")
+	}
+
+	clusterClient := getRedisClusterHelper(t)
+	redisCache := New[string, string](clusterClient)
+
+	testHelper(t, redisCache)
+
+	t.Run("batch keys spread across slots", func(t *testing.T) {
+		items := map[string]string{}
+		keys := make([]string, 0, 100)
+		for i := 0; i < 100; i++ {
+			k := uuid.New().String()
+			items[k] = fmt.Sprint(i)
+			keys = append(keys, k)
+		}
+
+		if errs := redisCache.SetMany(context.Background(), items, time.Minute); len(errs) != 0 {
+			t.Fatalf("could not set items: %v", errs)
+		}
+
+		vals, errs := redisCache.GetMany(context.Background(), keys)
+		if len(errs) != 0 || len(vals) != len(items) {
+			t.Fatalf("could not get items, got: %d values. errors: %v", len(vals), errs)
+		}
+
+		if errs := redisCache.DeleteMany(context.Background(), keys); len(errs) != 0 {
+			t.Fatalf("could not delete items: %v", errs)
+		}
+
+		vals, errs = redisCache.GetMany(context.Background(), keys)
+		if len(vals) != 0 || len(errs) != len(keys) {
+			t.Errorf("could not match deleted items, got: %d values", len(vals))
+		}
+	})
+
+	t.Run("hashtag keys share a slot", func(t *testing.T) {
+		tenant := uuid.New().String()
+		tagged := New[int, string](
+			clusterClient,
+			KeyEncoderOption[int, string](func(id int) (string, error) { return fmt.Sprintf("{%s}:%d", tenant, id), nil }),
+		)
+
+		if errs := tagged.SetMany(context.Background(), map[int]string{1: "1", 2: "2"}, time.Minute); len(errs) != 0 {
+			t.Fatalf("could not set items: %v", errs)
+		}
+
+		vals, errs := tagged.GetMany(context.Background(), []int{1, 2, 3})
+		if len(vals) != 2 || len(errs) != 1 || !errors.Is(errs[3], cache.ErrNotFound) {
+			t.Errorf("could not match items, got: %v %v", vals, errs)
+		}
+	})
+
+	t.Run("purge every node", func(t *testing.T) {
+		ns := uuid.New().String()
+		namespaced := New[string, string](clusterClient, NamespaceOption[string, string](ns))
+		keys := make([]string, 0, 100)
+		for i := 0; i < 100; i++ {
+			k := fmt.Sprint(i)
+			keys = append(keys, k)
+			if err := namespaced.Set(context.Background(), k, "value", time.Minute); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		if err := namespaced.Purge(context.Background()); err != nil {
+			t.Fatalf("could not purge namespace: %s", err)
+		}
+
+		if vals, _ := namespaced.GetMany(context.Background(), keys); len(vals) != 0 {
+			t.Errorf("could not match purged items, got: %d values", len(vals))
+		}
+	})
+}
+
+// getRedisClusterHelper starts a cluster of three masters and three replicas,
+// returning a client which maps the addresses announced by the nodes to the ones exposed by the container
+func getRedisClusterHelper(t *testing.T) *redis.ClusterClient {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ports := []string{"7000/tcp", "7001/tcp", "7002/tcp", "7003/tcp", "7004/tcp", "7005/tcp"}
+	req := testcontainers.ContainerRequest{
+		Image:        "grokzen/redis-cluster:7.0.10",
+		ExposedPorts: ports,
+		WaitingFor:   wait.ForLog("Cluster state changed: ok"),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+		Logger:           log.New(io.Discard, "", log.LstdFlags),
+	})
+	if err != nil {
+		t.Fatalf("could not start redis cluster container: %s", err)
+	}
+
+	t.Cleanup(func() {
+		_ = container.Terminate(context.Background())
+	})
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("could not get redis cluster host: %s", err)
+	}
+
+	addrs := map[string]string{}
+	for _, p := range ports {
+		mapped, err := container.MappedPort(ctx, nat.Port(p))
+		if err != nil {
+			t.Fatalf("could not get redis cluster mapped port: %s", err)
+		}
+		addrs[nat.Port(p).Port()] = net.JoinHostPort(host, mapped.Port())
+	}
+
+	seed := redis.NewClient(&redis.Options{Addr: addrs["7000"]})
+	t.Cleanup(func() { _ = seed.Close() })
+
+	cl := redis.NewClusterClient(&redis.ClusterOptions{
+		ClusterSlots: func(ctx context.Context) ([]redis.ClusterSlot, error) {
+			slots, err := seed.ClusterSlots(ctx).Result()
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range slots {
+				for j := range slots[i].Nodes {
+					_, port, err := net.SplitHostPort(slots[i].Nodes[j].Addr)
+					if err != nil {
+						return nil, err
+					}
+					slots[i].Nodes[j].Addr = addrs[port]
+				}
+			}
+			return slots, nil
+		},
+	})
+	t.Cleanup(func() { _ = cl.Close() })
+
+	return cl
+}
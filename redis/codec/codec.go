@@ -0,0 +1,82 @@
+// Package codec provides codecs, compression and encryption for the items of a redis.Redis.
+// It is a module of its own, so that only who uses them requires their dependencies
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/damianopetrungaro/go-cache/redis"
+)
+
+// MsgpackEncoder is an Encoder implementation which transforms data to msgpack
+func MsgpackEncoder[V any](val V) ([]byte, error) {
+	return msgpack.Marshal(val)
+}
+
+// MsgpackDecoder is a Decoder implementation which transforms data from msgpack
+func MsgpackDecoder[V any](data []byte, val V) error {
+	if err := msgpack.Unmarshal(data, val); err != nil {
+		return &redis.DecodeError{Codec: "msgpack", Err: err}
+	}
+	return nil
+}
+
+// GobEncoder is an Encoder implementation which transforms data with the encoding/gob package
+// Every item carries the description of its type, so it fits better large items than small ones
+func GobEncoder[V any](val V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecoder is a Decoder implementation which transforms data with the encoding/gob package
+func GobDecoder[V any](data []byte, val V) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(val); err != nil {
+		return &redis.DecodeError{Codec: "gob", Err: err}
+	}
+	return nil
+}
+
+// cborEncMode encodes the times as RFC 3339 strings with nanoseconds, as the default mode truncates them to seconds
+var cborEncMode, _ = cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+
+// CBOREncoder is an Encoder implementation which transforms data to CBOR
+func CBOREncoder[V any](val V) ([]byte, error) {
+	return cborEncMode.Marshal(val)
+}
+
+// CBORDecoder is a Decoder implementation which transforms data from CBOR
+func CBORDecoder[V any](data []byte, val V) error {
+	if err := cbor.Unmarshal(data, val); err != nil {
+		return &redis.DecodeError{Codec: "cbor", Err: err}
+	}
+	return nil
+}
+
+// ProtoEncoder is an Encoder implementation which transforms a proto.Message to the protobuf wire format
+func ProtoEncoder[M proto.Message](val M) ([]byte, error) {
+	return proto.Marshal(val)
+}
+
+// ProtoDecoder is a Decoder implementation which transforms data from the protobuf wire format
+// It allocates the proto.Message when the given one is nil, so that it can be used by a Redis[K, M]
+func ProtoDecoder[M proto.Message](data []byte, val *M) error {
+	msg := *val
+	if !msg.ProtoReflect().IsValid() {
+		msg = msg.ProtoReflect().Type().New().Interface().(M)
+	}
+
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return &redis.DecodeError{Codec: "protobuf", Err: err}
+	}
+
+	*val = msg
+	return nil
+}
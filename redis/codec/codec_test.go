@@ -0,0 +1,119 @@
+package codec_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/redis"
+	. "github.com/damianopetrungaro/go-cache/redis/codec"
+)
+
+type codecItem struct {
+	Name      string
+	CreatedAt time.Time
+	Data      []byte
+	Tags      []string
+}
+
+type codec struct {
+	enc redis.Encoder[codecItem]
+	dec redis.Decoder[*codecItem]
+}
+
+var codecs = map[string]codec{
+	"json":    {enc: redis.DefaultEncoder[codecItem], dec: redis.DefaultDecoder[*codecItem]},
+	"msgpack": {enc: MsgpackEncoder[codecItem], dec: MsgpackDecoder[*codecItem]},
+	"gob":     {enc: GobEncoder[codecItem], dec: GobDecoder[*codecItem]},
+	"cbor":    {enc: CBOREncoder[codecItem], dec: CBORDecoder[*codecItem]},
+}
+
+func newCodecItem() codecItem {
+	return codecItem{
+		Name:      "item",
+		CreatedAt: time.Date(2022, 6, 9, 10, 30, 0, 123456789, time.UTC),
+		Data:      bytes.Repeat([]byte{0, 1, 2, 255}, 64),
+		Tags:      []string{"one", "two", "three"},
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	for name, c := range codecs {
+		t.Run(name, func(t *testing.T) {
+			want := newCodecItem()
+			data, err := c.enc(want)
+			if err != nil {
+				t.Fatalf("could not encode item: %s", err)
+			}
+
+			var got codecItem
+			if err := c.dec(data, &got); err != nil {
+				t.Fatalf("could not decode item: %s", err)
+			}
+
+			if got.Name != want.Name || !got.CreatedAt.Equal(want.CreatedAt) || !bytes.Equal(got.Data, want.Data) || len(got.Tags) != len(want.Tags) {
+				t.Errorf("could not match decoded item, got: %v. want: %v", got, want)
+			}
+
+			err = c.dec([]byte{0xc1, 0xff, 0x00}, &got)
+			var decErr *redis.DecodeError
+			if !errors.As(err, &decErr) || decErr.Codec != name {
+				t.Errorf("could not match decode error, got: %v", err)
+			}
+
+			if !errors.Is(err, cache.ErrNotGet) {
+				t.Errorf("could not match not get error, got: %v", err)
+			}
+		})
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		want := timestamppb.New(time.Date(2022, 6, 9, 10, 30, 0, 123456789, time.UTC))
+		data, err := ProtoEncoder[*timestamppb.Timestamp](want)
+		if err != nil {
+			t.Fatalf("could not encode item: %s", err)
+		}
+
+		var got *timestamppb.Timestamp
+		if err := ProtoDecoder[*timestamppb.Timestamp](data, &got); err != nil {
+			t.Fatalf("could not decode item: %s", err)
+		}
+
+		if !got.AsTime().Equal(want.AsTime()) {
+			t.Errorf("could not match decoded item, got: %s. want: %s", got.AsTime(), want.AsTime())
+		}
+
+		if err := ProtoDecoder[*timestamppb.Timestamp]([]byte{0xff}, &got); !errors.Is(err, cache.ErrNotGet) {
+			t.Errorf("could not match not get error, got: %v", err)
+		}
+	})
+}
+
+func BenchmarkCodecs(b *testing.B) {
+	item := newCodecItem()
+	for name, c := range codecs {
+		data, err := c.enc(item)
+		if err != nil {
+			b.Fatalf("could not encode item: %s", err)
+		}
+
+		b.Run(name+"/encode", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = c.enc(item)
+			}
+		})
+
+		b.Run(name+"/decode", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var got codecItem
+				_ = c.dec(data, &got)
+			}
+		})
+	}
+}
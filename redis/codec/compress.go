@@ -0,0 +1,131 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/damianopetrungaro/go-cache/redis"
+)
+
+// Compression represents an algorithm used to compress the encoded items
+// Its value is the header byte prefixed to the compressed items
+type Compression byte
+
+// List of compressions accepted by Compress
+const (
+	NoCompression Compression = iota
+	Gzip
+	Zstd
+	Snappy
+	LZ4
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+	gzipWriters    = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+	lz4Writers     = sync.Pool{New: func() any { return lz4.NewWriter(nil) }}
+)
+
+// Compress wraps an Encoder and a Decoder so that the encoded items are compressed with the given Compression.
+// The items are prefixed by a header byte telling how they are compressed, and the ones smaller than the
+// minimum size are kept uncompressed. Items without a known header are decoded as they are,
+// so that the items stored before enabling the compression can still be read,
+// as long as they do not start with a byte between 0x00 and 0x04 as the JSON ones never do
+func Compress[V any](enc redis.Encoder[V], dec redis.Decoder[*V], c Compression, minSize int) (redis.Encoder[V], redis.Decoder[*V]) {
+	encoder := func(val V) ([]byte, error) {
+		data, err := enc(val)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(data) < minSize || c == NoCompression {
+			return append([]byte{byte(NoCompression)}, data...), nil
+		}
+
+		return compress(c, data)
+	}
+
+	decoder := func(data []byte, val *V) error {
+		data, err := decompress(data)
+		if err != nil {
+			return err
+		}
+		return dec(data, val)
+	}
+
+	return encoder, decoder
+}
+
+// compress returns the data compressed and prefixed by the header of the compression
+func compress(c Compression, data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)/2+1))
+	buf.WriteByte(byte(c))
+
+	switch c {
+	case Gzip:
+		w := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(w)
+		w.Reset(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		return zstdEncoder.EncodeAll(data, buf.Bytes()), nil
+	case Snappy:
+		out := snappy.Encode(nil, data)
+		return append(buf.Bytes(), out...), nil
+	case LZ4:
+		w := lz4Writers.Get().(*lz4.Writer)
+		defer lz4Writers.Put(w)
+		w.Reset(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown compression: %d", c)
+	}
+}
+
+// decompress returns the data decompressed as told by its header, or as it is when it has no known header
+func decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	payload := data[1:]
+	switch Compression(data[0]) {
+	case NoCompression:
+		return payload, nil
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case Zstd:
+		return zstdDecoder.DecodeAll(payload, nil)
+	case Snappy:
+		return snappy.Decode(nil, payload)
+	case LZ4:
+		return io.ReadAll(lz4.NewReader(bytes.NewReader(payload)))
+	default:
+		return data, nil
+	}
+}
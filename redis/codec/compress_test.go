@@ -0,0 +1,75 @@
+package codec_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/damianopetrungaro/go-cache/redis"
+	. "github.com/damianopetrungaro/go-cache/redis/codec"
+)
+
+func Test_Compress(t *testing.T) {
+	large := strings.Repeat("value ", 1_000)
+	compressions := map[string]Compression{
+		"none":   NoCompression,
+		"gzip":   Gzip,
+		"zstd":   Zstd,
+		"snappy": Snappy,
+		"lz4":    LZ4,
+	}
+
+	for name, c := range compressions {
+		t.Run(name, func(t *testing.T) {
+			enc, dec := Compress[string](redis.DefaultEncoder[string], redis.DefaultDecoder[*string], c, 100)
+
+			data, err := enc(large)
+			if err != nil {
+				t.Fatalf("could not encode item: %s", err)
+			}
+
+			if data[0] != byte(c) {
+				t.Errorf("could not match header, got: %d. want: %d", data[0], c)
+			}
+
+			if c != NoCompression && len(data) >= len(large) {
+				t.Errorf("could not compress item, got: %d bytes", len(data))
+			}
+
+			var got string
+			if err := dec(data, &got); err != nil {
+				t.Fatalf("could not decode item: %s", err)
+			}
+
+			if got != large {
+				t.Error("could not match decoded item")
+			}
+		})
+	}
+
+	t.Run("keep small items uncompressed", func(t *testing.T) {
+		enc, dec := Compress[string](redis.DefaultEncoder[string], redis.DefaultDecoder[*string], Zstd, 100)
+
+		data, err := enc("small")
+		if err != nil {
+			t.Fatalf("could not encode item: %s", err)
+		}
+
+		if data[0] != byte(NoCompression) || string(data[1:]) != `"small"` {
+			t.Errorf("could not match uncompressed item, got: %q", data)
+		}
+
+		var got string
+		if err := dec(data, &got); err != nil || got != "small" {
+			t.Errorf("could not decode item, got: %s %v", got, err)
+		}
+	})
+
+	t.Run("decode legacy items", func(t *testing.T) {
+		_, dec := Compress[string](redis.DefaultEncoder[string], redis.DefaultDecoder[*string], Gzip, 100)
+
+		var got string
+		if err := dec([]byte(`"legacy"`), &got); err != nil || got != "legacy" {
+			t.Errorf("could not decode legacy item, got: %s %v", got, err)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/redis"
+)
+
+// envelopeVersion is the first byte of the encrypted items, telling the format of their envelope
+const envelopeVersion byte = 1
+
+// ErrUnknownKey is the error returned when an item is encrypted by a key missing from the Keyring
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Algorithm represents an AEAD algorithm used to encrypt the encoded items
+type Algorithm byte
+
+// List of algorithms accepted by a Keyring
+const (
+	// AESGCM requires secrets of 16, 24 or 32 bytes, selecting AES-128, AES-192 or AES-256
+	AESGCM Algorithm = iota + 1
+	// XChaCha20Poly1305 requires secrets of 32 bytes, and its random nonces are safe for any number of items
+	XChaCha20Poly1305
+)
+
+// Key represents a secret used to encrypt the items, referred by its ID in their envelope
+type Key struct {
+	ID        string
+	Secret    []byte
+	Algorithm Algorithm
+}
+
+// DecryptError is the error returned by the decoder of Encrypt when an item cannot be decrypted,
+// because it was tampered, moved to another redis key, or encrypted by an unknown key
+// It is a cache.ErrNotGet, and keeps the reason in the chain
+type DecryptError struct {
+	KeyID string
+	Err   error
+}
+
+func (e *DecryptError) Error() string {
+	return fmt.Sprintf("%s: could not decrypt item with key %q: %s", cache.ErrNotGet, e.KeyID, e.Err)
+}
+
+func (e *DecryptError) Is(target error) bool {
+	return errors.Is(cache.ErrNotGet, target)
+}
+
+func (e *DecryptError) Unwrap() error {
+	return e.Err
+}
+
+// Keyring holds the keys used to encrypt and decrypt the items
+// The items are encrypted by the current key, and decrypted by the key whose ID is in their envelope,
+// so that a key can be rotated by making it current while keeping the previous ones until their items expire
+type Keyring struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyring returns a Keyring encrypting with the current key, and decrypting with it and the previous ones
+func NewKeyring(current Key, previous ...Key) (*Keyring, error) {
+	kr := &Keyring{
+		current: current.ID,
+		aeads:   make(map[string]cipher.AEAD, len(previous)+1),
+	}
+
+	for _, k := range append([]Key{current}, previous...) {
+		if len(k.ID) > 255 {
+			return nil, fmt.Errorf("could not use key %q: its id is longer than 255 bytes", k.ID)
+		}
+
+		if _, ok := kr.aeads[k.ID]; ok {
+			return nil, fmt.Errorf("could not use key %q: its id is duplicated", k.ID)
+		}
+
+		aead, err := newAEAD(k)
+		if err != nil {
+			return nil, fmt.Errorf("could not use key %q: %w", k.ID, err)
+		}
+		kr.aeads[k.ID] = aead
+	}
+
+	return kr, nil
+}
+
+// Encrypt wraps an Encoder and a Decoder so that the encoded items are encrypted by the Keyring.
+// The redis key of an item is bound as associated data, so that an item cannot be swapped with the one of another key.
+// An item which cannot be decrypted fails with a DecryptError
+func Encrypt[V any](enc redis.Encoder[V], dec redis.Decoder[*V], kr *Keyring) (redis.KeyedEncoder[V], redis.KeyedDecoder[*V]) {
+	encoder := func(key string, val V) ([]byte, error) {
+		data, err := enc(val)
+		if err != nil {
+			return nil, err
+		}
+		return kr.seal(key, data)
+	}
+
+	decoder := func(key string, data []byte, val *V) error {
+		data, err := kr.open(key, data)
+		if err != nil {
+			return err
+		}
+		return dec(data, val)
+	}
+
+	return encoder, decoder
+}
+
+// seal returns the data encrypted by the current key, in an envelope made of
+// the version, the length of the key id, the key id, the nonce and the ciphertext
+func (kr *Keyring) seal(key string, data []byte) ([]byte, error) {
+	aead := kr.aeads[kr.current]
+
+	header := make([]byte, 0, 2+len(kr.current))
+	header = append(header, envelopeVersion, byte(len(kr.current)))
+	header = append(header, kr.current...)
+
+	out := make([]byte, len(header)+aead.NonceSize(), len(header)+aead.NonceSize()+len(data)+aead.Overhead())
+	copy(out, header)
+	nonce := out[len(header):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("could not generate nonce: %w", err)
+	}
+
+	return aead.Seal(out, nonce, data, additionalData(header, key)), nil
+}
+
+// open returns the data decrypted by the key whose id is in the envelope
+func (kr *Keyring) open(key string, data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != envelopeVersion || len(data) < 2+int(data[1]) {
+		return nil, &DecryptError{Err: errors.New("malformed envelope")}
+	}
+
+	header := data[:2+int(data[1])]
+	id := string(header[2:])
+	aead, ok := kr.aeads[id]
+	if !ok {
+		return nil, &DecryptError{KeyID: id, Err: ErrUnknownKey}
+	}
+
+	payload := data[len(header):]
+	if len(payload) < aead.NonceSize() {
+		return nil, &DecryptError{KeyID: id, Err: errors.New("malformed envelope")}
+	}
+
+	nonce, ciphertext := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+	out, err := aead.Open(nil, nonce, ciphertext, additionalData(header, key))
+	if err != nil {
+		return nil, &DecryptError{KeyID: id, Err: err}
+	}
+	return out, nil
+}
+
+// additionalData binds both the envelope header and the redis key to the ciphertext
+func additionalData(header []byte, key string) []byte {
+	ad := make([]byte, 0, len(header)+len(key))
+	ad = append(ad, header...)
+	return append(ad, key...)
+}
+
+func newAEAD(k Key) (cipher.AEAD, error) {
+	switch k.Algorithm {
+	case AESGCM:
+		block, err := aes.NewCipher(k.Secret)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case XChaCha20Poly1305:
+		return chacha20poly1305.NewX(k.Secret)
+	default:
+		return nil, fmt.Errorf("unknown algorithm: %d", k.Algorithm)
+	}
+}
@@ -0,0 +1,147 @@
+package codec_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/redis"
+	. "github.com/damianopetrungaro/go-cache/redis/codec"
+)
+
+func Test_Encrypt(t *testing.T) {
+	keys := map[string]Key{
+		"aes-128-gcm":        {ID: "aes-128", Secret: bytes.Repeat([]byte{1}, 16), Algorithm: AESGCM},
+		"aes-256-gcm":        {ID: "aes-256", Secret: bytes.Repeat([]byte{2}, 32), Algorithm: AESGCM},
+		"xchacha20-poly1305": {ID: "xchacha", Secret: bytes.Repeat([]byte{3}, 32), Algorithm: XChaCha20Poly1305},
+	}
+
+	for name, k := range keys {
+		t.Run(name, func(t *testing.T) {
+			kr, err := NewKeyring(k)
+			if err != nil {
+				t.Fatalf("could not create keyring: %s", err)
+			}
+			enc, dec := Encrypt[string](redis.DefaultEncoder[string], redis.DefaultDecoder[*string], kr)
+
+			data, err := enc("users:1", "secret value")
+			if err != nil {
+				t.Fatalf("could not encode item: %s", err)
+			}
+
+			if bytes.Contains(data, []byte("secret value")) {
+				t.Error("could not encrypt item")
+			}
+
+			var got string
+			if err := dec("users:1", data, &got); err != nil {
+				t.Fatalf("could not decode item: %s", err)
+			}
+
+			if got != "secret value" {
+				t.Errorf("could not match decoded item, got: %s", got)
+			}
+		})
+	}
+
+	current := Key{ID: "2", Secret: bytes.Repeat([]byte{2}, 32), Algorithm: XChaCha20Poly1305}
+	previous := Key{ID: "1", Secret: bytes.Repeat([]byte{1}, 32), Algorithm: AESGCM}
+	kr, err := NewKeyring(current, previous)
+	if err != nil {
+		t.Fatalf("could not create keyring: %s", err)
+	}
+	enc, dec := Encrypt[string](redis.DefaultEncoder[string], redis.DefaultDecoder[*string], kr)
+
+	t.Run("decrypt items of previous keys", func(t *testing.T) {
+		old, err := NewKeyring(previous)
+		if err != nil {
+			t.Fatalf("could not create keyring: %s", err)
+		}
+		oldEnc, _ := Encrypt[string](redis.DefaultEncoder[string], redis.DefaultDecoder[*string], old)
+
+		data, err := oldEnc("users:1", "value")
+		if err != nil {
+			t.Fatalf("could not encode item: %s", err)
+		}
+
+		var got string
+		if err := dec("users:1", data, &got); err != nil || got != "value" {
+			t.Errorf("could not decode item, got: %s %v", got, err)
+		}
+	})
+
+	t.Run("fail on swapped items", func(t *testing.T) {
+		data, err := enc("users:1", "value")
+		if err != nil {
+			t.Fatalf("could not encode item: %s", err)
+		}
+
+		var got string
+		err = dec("users:2", data, &got)
+		var decErr *DecryptError
+		if !errors.As(err, &decErr) || decErr.KeyID != "2" {
+			t.Errorf("could not match decrypt error, got: %v", err)
+		}
+
+		if !errors.Is(err, cache.ErrNotGet) {
+			t.Errorf("could not match not get error, got: %v", err)
+		}
+	})
+
+	t.Run("fail on tampered items", func(t *testing.T) {
+		data, err := enc("users:1", "value")
+		if err != nil {
+			t.Fatalf("could not encode item: %s", err)
+		}
+
+		for _, i := range []int{2, len(data) / 2, len(data) - 1} {
+			tampered := append([]byte{}, data...)
+			tampered[i] ^= 1
+
+			var got string
+			var decErr *DecryptError
+			if err := dec("users:1", tampered, &got); !errors.As(err, &decErr) || !errors.Is(err, cache.ErrNotGet) {
+				t.Errorf("could not match decrypt error on byte %d, got: %v", i, err)
+			}
+		}
+
+		var got string
+		var decErr *DecryptError
+		if err := dec("users:1", []byte(`"plain"`), &got); !errors.As(err, &decErr) {
+			t.Errorf("could not match decrypt error on plain item, got: %v", err)
+		}
+	})
+
+	t.Run("fail on unknown keys", func(t *testing.T) {
+		other, err := NewKeyring(Key{ID: "3", Secret: bytes.Repeat([]byte{3}, 16), Algorithm: AESGCM})
+		if err != nil {
+			t.Fatalf("could not create keyring: %s", err)
+		}
+		otherEnc, _ := Encrypt[string](redis.DefaultEncoder[string], redis.DefaultDecoder[*string], other)
+
+		data, err := otherEnc("users:1", "value")
+		if err != nil {
+			t.Fatalf("could not encode item: %s", err)
+		}
+
+		var got string
+		if err := dec("users:1", data, &got); !errors.Is(err, ErrUnknownKey) || !errors.Is(err, cache.ErrNotGet) {
+			t.Errorf("could not match unknown key error, got: %v", err)
+		}
+	})
+
+	t.Run("reject invalid keys", func(t *testing.T) {
+		invalid := map[string][]Key{
+			"short secret":      {{ID: "1", Secret: []byte("short"), Algorithm: AESGCM}},
+			"unknown algorithm": {{ID: "1", Secret: bytes.Repeat([]byte{1}, 32)}},
+			"duplicated id":     {current, {ID: "2", Secret: bytes.Repeat([]byte{1}, 16), Algorithm: AESGCM}},
+		}
+
+		for name, keys := range invalid {
+			if _, err := NewKeyring(keys[0], keys[1:]...); err == nil {
+				t.Errorf("could not reject %s", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,25 @@
+module github.com/damianopetrungaro/go-cache/redis/codec
+
+go 1.18
+
+require (
+	github.com/damianopetrungaro/go-cache v0.0.0
+	github.com/fxamacker/cbor/v2 v2.6.0
+	github.com/golang/snappy v0.0.4
+	github.com/klauspost/compress v1.17.0
+	github.com/pierrec/lz4/v4 v4.1.18
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	golang.org/x/crypto v0.21.0
+	google.golang.org/protobuf v1.27.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.1.2 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-redis/redis/v9 v9.0.0-beta.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+)
+
+replace github.com/damianopetrungaro/go-cache v0.0.0 => ./../../
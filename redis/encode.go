@@ -2,10 +2,23 @@ package redis
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/damianopetrungaro/go-cache"
 )
 
 // EncodeDecodeOption represents an Option which specify a strategy to encode and decode the items in the cache
-func EncodeDecodeOption[K string, V any](enc Encoder[V], dec Decoder[*V]) Option[K, V] {
+func EncodeDecodeOption[K comparable, V any](enc Encoder[V], dec Decoder[*V]) Option[K, V] {
+	return KeyedEncodeDecodeOption[K, V](
+		func(_ string, val V) ([]byte, error) { return enc(val) },
+		func(_ string, data []byte, val *V) error { return dec(data, val) },
+	)
+}
+
+// KeyedEncodeDecodeOption represents an Option which specify a strategy to encode and decode the items in the cache
+// bound to their redis keys, such as the one returned by codec.Encrypt
+func KeyedEncodeDecodeOption[K comparable, V any](enc KeyedEncoder[V], dec KeyedDecoder[*V]) Option[K, V] {
 	return func(r *Redis[K, V]) {
 		r.enc = enc
 		r.dec = dec
@@ -19,6 +32,12 @@ type Encoder[V any] func(val V) ([]byte, error)
 // Decoder represents a function used to decode an item as []byte to persist on redis
 type Decoder[V any] func(data []byte, val V) error
 
+// KeyedEncoder represents a function used to encode an item stored under the given redis key
+type KeyedEncoder[V any] func(key string, val V) ([]byte, error)
+
+// KeyedDecoder represents a function used to decode an item stored under the given redis key
+type KeyedDecoder[V any] func(key string, data []byte, val V) error
+
 // DefaultEncoder is a default implementation of an Encoder. It transforms data to JSON.
 func DefaultEncoder[V any](val V) ([]byte, error) {
 	return json.Marshal(val)
@@ -27,8 +46,27 @@ func DefaultEncoder[V any](val V) ([]byte, error) {
 // DefaultDecoder is a default implementation of a Decoder. It transforms data from JSON.
 func DefaultDecoder[V any](data []byte, val V) error {
 	if err := json.Unmarshal(data, val); err != nil {
-		return err
+		return &DecodeError{Codec: "json", Err: err}
 	}
 
 	return nil
 }
+
+// DecodeError is the error returned by the decoders of the package when an item cannot be decoded
+// It is a cache.ErrNotGet, and keeps the error of the codec in the chain
+type DecodeError struct {
+	Codec string
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: could not decode %s item: %s", cache.ErrNotGet, e.Codec, e.Err)
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return errors.Is(cache.ErrNotGet, target)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
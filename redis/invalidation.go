@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/go-redis/redis/v9"
+
+	"github.com/damianopetrungaro/go-cache"
+)
+
+var _ cache.InvalidationBus[string] = &InvalidationBus[string]{}
+
+// InvalidationBus is a cache.InvalidationBus implementation which broadcasts the invalidations through a redis Pub/Sub channel
+// The invalidations are encoded as JSON, and the ones which cannot be decoded are dropped
+type InvalidationBus[K comparable] struct {
+	cl      redis.UniversalClient
+	channel string
+}
+
+// NewInvalidationBus returns an InvalidationBus publishing to the given channel
+func NewInvalidationBus[K comparable](cl redis.UniversalClient, channel string) *InvalidationBus[K] {
+	return &InvalidationBus[K]{
+		cl:      cl,
+		channel: channel,
+	}
+}
+
+// Publish sends the invalidation to the channel
+func (b *InvalidationBus[K]) Publish(ctx context.Context, inv cache.Invalidation[K]) error {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("%w:%s", cache.ErrNotPublish, err)
+	}
+
+	if err := b.cl.Publish(ctx, b.channel, data).Err(); err != nil {
+		return fmt.Errorf("%w:%s", cache.ErrNotPublish, err)
+	}
+	return nil
+}
+
+// Subscribe calls the handler for every invalidation received from the channel, reconnecting when the connection drops
+// Invalidations published while disconnected are lost, as redis Pub/Sub does not persist messages
+func (b *InvalidationBus[K]) Subscribe(handler func(cache.Invalidation[K])) cache.Subscription {
+	s := &subscription{ps: b.cl.Subscribe(context.Background(), b.channel)}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for msg := range s.ps.Channel() {
+			var inv cache.Invalidation[K]
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				continue
+			}
+			handler(inv)
+		}
+	}()
+	return s
+}
+
+// subscription is a cache.Subscription waiting for the handler to return on Close
+type subscription struct {
+	ps *redis.PubSub
+	wg sync.WaitGroup
+}
+
+func (s *subscription) Close() error {
+	err := s.ps.Close()
+	s.wg.Wait()
+	return err
+}
@@ -0,0 +1,60 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+
+	"github.com/damianopetrungaro/go-cache"
+	. "github.com/damianopetrungaro/go-cache/redis"
+)
+
+func TestInvalidationBus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test")
+	}
+
+	options, err := redis.ParseURL(getRedisUriHelper(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("deliver published invalidations", func(t *testing.T) {
+		cl := redis.NewClient(options)
+		bus := NewInvalidationBus[string](cl, "invalidations")
+
+		got := make(chan cache.Invalidation[string], 1)
+		sub := bus.Subscribe(func(inv cache.Invalidation[string]) {
+			// an invalidation published many times may be delivered many times
+			select {
+			case got <- inv:
+			default:
+			}
+		})
+		t.Cleanup(func() { _ = sub.Close() })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		want := cache.Invalidation[string]{Origin: "origin", Key: "key"}
+		// the subscription is established asynchronously, so the invalidation is published until received
+		for {
+			if err := bus.Publish(ctx, want); err != nil {
+				t.Fatalf("could not publish invalidation: %s", err)
+			}
+
+			select {
+			case inv := <-got:
+				if inv != want {
+					t.Errorf("could not match invalidation, got: %v. want: %v", inv, want)
+				}
+				return
+			case <-ctx.Done():
+				t.Fatal("could not receive invalidation before the deadline")
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	})
+}
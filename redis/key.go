@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// KeyEncoderOption represents an Option which specify a strategy to encode the keys into redis ones
+func KeyEncoderOption[K comparable, V any](enc KeyEncoder[K]) Option[K, V] {
+	return func(r *Redis[K, V]) {
+		r.keyEnc = enc
+	}
+}
+
+// KeyEncoder represents a function used to encode a key as a redis one
+// Distinct keys must be encoded as distinct redis keys
+type KeyEncoder[K comparable] func(key K) (string, error)
+
+// ErrUnsupportedKey is returned by DefaultKeyEncoder for the keys which cannot be encoded safely, requiring a KeyEncoder
+var ErrUnsupportedKey = errors.New("unsupported key type, a KeyEncoder is required")
+
+// DefaultKeyEncoder is a default implementation of a KeyEncoder.
+// It encodes encoding.TextMarshaler types with MarshalText, fmt.Stringer types with String, string types as they are,
+// integer types in base 10, and booleans, floats and complex numbers with the strconv package.
+// MarshalText and String must return distinct representations for distinct keys.
+// Any other type, such as structs, arrays, pointers and interfaces, fails with an ErrUnsupportedKey:
+// their representation could be shared by distinct keys, or be valid within the process only
+func DefaultKeyEncoder[K comparable](key K) (string, error) {
+	// distinct dynamic values of an interface type may share the same representation, such as 1 and "1"
+	if t := reflect.TypeOf((*K)(nil)).Elem(); t.Kind() == reflect.Interface {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedKey, t)
+	}
+
+	switch k := any(key).(type) {
+	case string:
+		return k, nil
+	case encoding.TextMarshaler:
+		data, err := k.MarshalText()
+		return string(data), err
+	case fmt.Stringer:
+		return k.String(), nil
+	}
+
+	v := reflect.ValueOf(key)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(unsignedZero(v.Float()), 'g', -1, v.Type().Bits()), nil
+	case reflect.Complex64, reflect.Complex128:
+		c := complex(unsignedZero(real(v.Complex())), unsignedZero(imag(v.Complex())))
+		return strconv.FormatComplex(c, 'g', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedKey, v.Type())
+	}
+}
+
+// unsignedZero returns +0 in place of -0, as they are equal keys which must share the same redis key
+func unsignedZero(f float64) float64 {
+	if f == 0 {
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,76 @@
+package redis_test
+
+import (
+	"errors"
+	"math"
+	"net/netip"
+	"strconv"
+	"testing"
+
+	. "github.com/damianopetrungaro/go-cache/redis"
+)
+
+type userID int64
+
+type tenant string
+
+type pair struct {
+	a, b int
+}
+
+type orderID struct {
+	tenant string
+	id     int
+}
+
+func (o orderID) String() string {
+	return o.tenant + "/" + strconv.Itoa(o.id)
+}
+
+func Test_DefaultKeyEncoder(t *testing.T) {
+	tests := map[string]struct {
+		encode func() (string, error)
+		want   string
+	}{
+		"string":       {encode: func() (string, error) { return DefaultKeyEncoder("key") }, want: "key"},
+		"string type":  {encode: func() (string, error) { return DefaultKeyEncoder(tenant("acme")) }, want: "acme"},
+		"int":          {encode: func() (string, error) { return DefaultKeyEncoder(-42) }, want: "-42"},
+		"int type":     {encode: func() (string, error) { return DefaultKeyEncoder(userID(42)) }, want: "42"},
+		"uint":         {encode: func() (string, error) { return DefaultKeyEncoder(uint8(7)) }, want: "7"},
+		"text marshal": {encode: func() (string, error) { return DefaultKeyEncoder(netip.MustParseAddr("127.0.0.1")) }, want: "127.0.0.1"},
+		"stringer":     {encode: func() (string, error) { return DefaultKeyEncoder(orderID{tenant: "acme", id: 42}) }, want: "acme/42"},
+		"bool":         {encode: func() (string, error) { return DefaultKeyEncoder(true) }, want: "true"},
+		"float":        {encode: func() (string, error) { return DefaultKeyEncoder(1.5) }, want: "1.5"},
+		"negative 0":   {encode: func() (string, error) { return DefaultKeyEncoder(math.Copysign(0, -1)) }, want: "0"},
+		"complex":      {encode: func() (string, error) { return DefaultKeyEncoder(complex64(1 + 2i)) }, want: "(1+2i)"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := test.encode()
+			if err != nil {
+				t.Fatalf("could not encode key: %s", err)
+			}
+
+			if got != test.want {
+				t.Errorf("could not match encoded key, got: %s. want: %s", got, test.want)
+			}
+		})
+	}
+}
+
+func Test_DefaultKeyEncoder_Unsupported(t *testing.T) {
+	tests := map[string]func() (string, error){
+		"struct":  func() (string, error) { return DefaultKeyEncoder(pair{a: 1, b: 2}) },
+		"array":   func() (string, error) { return DefaultKeyEncoder([2]string{"a b", ""}) },
+		"pointer": func() (string, error) { return DefaultKeyEncoder(&pair{a: 1, b: 2}) },
+	}
+
+	for name, encode := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := encode(); !errors.Is(err, ErrUnsupportedKey) {
+				t.Errorf("could not match unsupported key error, got: %v", err)
+			}
+		})
+	}
+}
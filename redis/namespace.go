@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-redis/redis/v9"
+
+	"github.com/damianopetrungaro/go-cache"
+)
+
+// purgeBatch is the number of keys scanned and deleted at once by Purge
+const purgeBatch = 1000
+
+// NamespaceOption represents an Option which prefixes every key with the given namespace and the separator
+func NamespaceOption[K comparable, V any](namespace string) Option[K, V] {
+	return func(r *Redis[K, V]) {
+		r.namespace = namespace
+	}
+}
+
+// SeparatorOption represents an Option which sets the separator between the namespace, its version and the key
+// It defaults to a colon
+func SeparatorOption[K comparable, V any](sep string) Option[K, V] {
+	return func(r *Redis[K, V]) {
+		r.sep = sep
+	}
+}
+
+// NamespaceVersionOption represents an Option which adds a version to the namespace.
+// Bumping the version logically invalidates every key of the previous one, which can be then removed by the Purge of a Redis using it.
+// Without a namespace the version alone prefixes the keys, but they cannot be removed by Purge
+func NamespaceVersionOption[K comparable, V any](version string) Option[K, V] {
+	return func(r *Redis[K, V]) {
+		r.version = version
+	}
+}
+
+// Purge removes every key of the namespace version, or of the namespace across all its versions when no version is set.
+// The keys are matched by their prefix only, so the ones of a nested namespace, such as "users:admin" for "users",
+// are removed as well when no version is set, or when the nested namespace starts with the separator and the version.
+// The keys are scanned and unlinked in batches, so that redis is never blocked by a single command.
+// It returns an error when no namespace is set, to prevent removing the keys of other services
+func (r *Redis[K, V]) Purge(ctx context.Context) error {
+	if r.namespace == "" {
+		return fmt.Errorf("%w:%s", cache.ErrNotDelete, "could not purge without a namespace")
+	}
+
+	match := globEscape(r.prefix) + "*"
+	var err error
+	switch cl := r.cl.(type) {
+	case *redis.ClusterClient:
+		err = cl.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return purge(ctx, node, match)
+		})
+	case *redis.Ring:
+		err = cl.ForEachShard(ctx, func(ctx context.Context, shard *redis.Client) error {
+			return purge(ctx, shard, match)
+		})
+	default:
+		err = purge(ctx, r.cl, match)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w:%s", cache.ErrNotDelete, err)
+	}
+	return nil
+}
+
+// key returns the redis key of the given key
+func (r *Redis[K, V]) key(k K) (string, error) {
+	key, err := r.keyEnc(k)
+	if err != nil {
+		return "", err
+	}
+	return r.prefix + key, nil
+}
+
+// purge unlinks the keys matching the pattern on a single node
+// Every key is unlinked on its own, as the keys of a node may belong to different cluster slots
+func purge(ctx context.Context, cl redis.Cmdable, match string) error {
+	var cursor uint64
+	for {
+		keys, next, err := cl.Scan(ctx, cursor, match, purgeBatch).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if _, err := cl.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, k := range keys {
+					pipe.Unlink(ctx, k)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// globEscape escapes the special characters of the redis glob-style patterns
+func globEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,124 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+
+	. "github.com/damianopetrungaro/go-cache/redis"
+)
+
+func TestNamespace(t *testing.T) {
+	tests := map[string]struct {
+		opts []Option[string, string]
+		want string
+	}{
+		"no namespace": {
+			want: "key",
+		},
+		"namespace": {
+			opts: []Option[string, string]{NamespaceOption[string, string]("orders")},
+			want: "orders:key",
+		},
+		"namespace and version": {
+			opts: []Option[string, string]{NamespaceOption[string, string]("orders"), NamespaceVersionOption[string, string]("v2")},
+			want: "orders:v2:key",
+		},
+		"version without namespace": {
+			opts: []Option[string, string]{NamespaceVersionOption[string, string]("v2")},
+			want: "v2:key",
+		},
+		"separator": {
+			opts: []Option[string, string]{NamespaceOption[string, string]("orders"), NamespaceVersionOption[string, string]("v2"), SeparatorOption[string, string]("/")},
+			want: "orders/v2/key",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cl := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+			t.Cleanup(func() { _ = cl.Close() })
+			h := &keyHook{}
+			cl.AddHook(h)
+
+			_, _ = New[string, string](cl, test.opts...).Get(context.Background(), "key")
+			if h.key != test.want {
+				t.Errorf("could not match key, got: %s. want: %s", h.key, test.want)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	tests := map[string]struct {
+		opts []Option[string, string]
+		want string
+	}{
+		"namespace": {
+			opts: []Option[string, string]{NamespaceOption[string, string]("users")},
+			want: "users:*",
+		},
+		"namespace and version": {
+			opts: []Option[string, string]{NamespaceOption[string, string]("users"), NamespaceVersionOption[string, string]("v2")},
+			want: "users:v2:*",
+		},
+		"nested namespace and version": {
+			opts: []Option[string, string]{NamespaceOption[string, string]("users:admin"), NamespaceVersionOption[string, string]("v2")},
+			want: "users:admin:v2:*",
+		},
+		"special characters": {
+			opts: []Option[string, string]{NamespaceOption[string, string]("users*"), NamespaceVersionOption[string, string]("[v2]")},
+			want: `users\*:\[v2\]:*`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cl := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+			t.Cleanup(func() { _ = cl.Close() })
+			h := &keyHook{}
+			cl.AddHook(h)
+
+			_ = New[string, string](cl, test.opts...).Purge(context.Background())
+			if h.match != test.want {
+				t.Errorf("could not match purge pattern, got: %s. want: %s", h.match, test.want)
+			}
+		})
+	}
+}
+
+// errHooked is returned by keyHook instead of sending the command to redis
+var errHooked = errors.New("hooked")
+
+// keyHook records the key and the match pattern of the last command, without sending it to redis
+type keyHook struct {
+	key   string
+	match string
+}
+
+func (h *keyHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	args := cmd.Args()
+	if len(args) > 1 {
+		h.key, _ = args[1].(string)
+	}
+	for i := 1; i < len(args)-1; i++ {
+		if args[i] == "match" {
+			h.match, _ = args[i+1].(string)
+		}
+	}
+	return ctx, errHooked
+}
+
+func (h *keyHook) AfterProcess(context.Context, redis.Cmder) error {
+	return nil
+}
+
+func (h *keyHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return ctx, errHooked
+}
+
+func (h *keyHook) AfterProcessPipeline(context.Context, []redis.Cmder) error {
+	return nil
+}
@@ -2,46 +2,81 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v9"
 
 	"github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/internal/stats"
 )
 
-var _ cache.Cache[string, string] = &Redis[string, string]{}
+var (
+	_ cache.Cache[string, string]      = &Redis[string, string]{}
+	_ cache.TTLer[string]              = &Redis[string, string]{}
+	_ cache.BatchCache[string, string] = &Redis[string, string]{}
+	_ cache.StatsReporter              = &Redis[string, string]{}
+)
 
 // Option represent a function which applies changes to a Redis cache instance
-type Option[K string, V any] func(*Redis[K, V])
+type Option[K comparable, V any] func(*Redis[K, V])
 
 // Redis is a cache.Cache implementation which interacts with a redis server
-type Redis[K string, V any] struct {
+type Redis[K comparable, V any] struct {
 	cl                 redis.Cmdable
-	enc                Encoder[V]
-	dec                Decoder[*V]
+	keyEnc             KeyEncoder[K]
+	namespace          string
+	version            string
+	sep                string
+	prefix             string
+	enc                KeyedEncoder[V]
+	dec                KeyedDecoder[*V]
 	shouldEncodeDecode bool
+	stats              *stats.Counters
 }
 
 // New returns a Redis instance
-func New[K string, V any](cl redis.Cmdable, opts ...Option[K, V]) *Redis[K, V] {
+func New[K comparable, V any](cl redis.Cmdable, opts ...Option[K, V]) *Redis[K, V] {
 	r := &Redis[K, V]{
-		cl: cl,
+		cl:     cl,
+		keyEnc: DefaultKeyEncoder[K],
+		sep:    ":",
+		stats:  &stats.Counters{},
 	}
 
 	for _, o := range opts {
 		o(r)
 	}
 
+	if r.namespace != "" {
+		r.prefix = r.namespace + r.sep
+	}
+	if r.version != "" {
+		r.prefix += r.version + r.sep
+	}
+
 	return r
 }
 
 // Get retrieves an item from a redis server
 func (r *Redis[K, V]) Get(ctx context.Context, k K) (V, error) {
+	val, err := r.get(ctx, k)
+	r.recordGet(err)
+	return val, err
+}
+
+// get retrieves an item, without counting the read
+func (r *Redis[K, V]) get(ctx context.Context, k K) (V, error) {
+	key, err := r.key(k)
+	if err != nil {
+		return *new(V), fmt.Errorf("%w:%s", cache.ErrNotGet, err)
+	}
+
 	val := new(V)
 	switch r.shouldEncodeDecode {
 	case false:
-		switch err := r.cl.Get(ctx, string(k)).Scan(val); {
+		switch err := r.cl.Get(ctx, key).Scan(val); {
 		case err == redis.Nil:
 			return *new(V), fmt.Errorf("%w:%s", cache.ErrNotFound, err)
 		case err == nil:
@@ -50,7 +85,7 @@ func (r *Redis[K, V]) Get(ctx context.Context, k K) (V, error) {
 			return *new(V), fmt.Errorf("%w:%s", cache.ErrNotGet, err)
 		}
 	default:
-		data, err := r.cl.Get(ctx, string(k)).Bytes()
+		data, err := r.cl.Get(ctx, key).Bytes()
 		switch {
 		case err == redis.Nil:
 			return *new(V), fmt.Errorf("%w:%s", cache.ErrNotFound, err)
@@ -58,8 +93,8 @@ func (r *Redis[K, V]) Get(ctx context.Context, k K) (V, error) {
 			return *new(V), fmt.Errorf("%w:%s", cache.ErrNotGet, err)
 		}
 
-		if err := r.dec(data, val); err != nil {
-			return *new(V), fmt.Errorf("%w:%s", cache.ErrNotGet, err)
+		if err := r.dec(key, data, val); err != nil {
+			return *new(V), decodeError(err)
 		}
 	}
 	return *val, nil
@@ -67,30 +102,275 @@ func (r *Redis[K, V]) Get(ctx context.Context, k K) (V, error) {
 
 // Set stores an item to a redis server
 func (r *Redis[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
+	key, err := r.key(k)
+	if err != nil {
+		return fmt.Errorf("%w:%s", cache.ErrNotSet, err)
+	}
 
 	switch r.shouldEncodeDecode {
 	case false:
-		if err := r.cl.Set(ctx, string(k), v, ttl).Err(); err != nil {
+		if err := r.cl.Set(ctx, key, v, ttl).Err(); err != nil {
 			return fmt.Errorf("%w:%s", cache.ErrNotSet, err)
 		}
 	default:
-		data, err := r.enc(v)
+		data, err := r.enc(key, v)
 		if err != nil {
 			return fmt.Errorf("%w:%s", cache.ErrNotSet, err)
 		}
 
-		if err := r.cl.Set(ctx, string(k), data, ttl).Err(); err != nil {
+		if err := r.cl.Set(ctx, key, data, ttl).Err(); err != nil {
 			return fmt.Errorf("%w:%s", cache.ErrNotSet, err)
 		}
 	}
 
+	r.stats.Sets.Inc()
 	return nil
 }
 
+// GetMany retrieves many items from a redis server with a single MGET,
+// or with a pipeline of MGET by hash slot on a cluster and of GET by key on a ring
+func (r *Redis[K, V]) GetMany(ctx context.Context, ks []K) (map[K]V, map[K]error) {
+	vals := make(map[K]V, len(ks))
+	keys, ks, errs := r.encodeKeys(ks, cache.ErrNotGet)
+	// the reads are counted once all of them are done
+	defer func() {
+		r.stats.Hits.Add(len(vals))
+		for _, err := range errs {
+			r.recordGet(err)
+		}
+	}()
+	if len(keys) == 0 {
+		return vals, errs
+	}
+
+	gs := groups(r.cl, keys)
+	pipe := r.cl.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(gs))
+	for i, g := range gs {
+		gKeys := make([]string, len(g))
+		for j, idx := range g {
+			gKeys[j] = keys[idx]
+		}
+		cmds[i] = pipe.MGet(ctx, gKeys...)
+	}
+	// the error of the pipeline is the one of its first failed command, so the commands are checked one by one
+	_, _ = pipe.Exec(ctx)
+
+	for i, g := range gs {
+		res, err := cmds[i].Result()
+		for j, idx := range g {
+			k := ks[idx]
+			if err != nil {
+				if errs == nil {
+					errs = map[K]error{}
+				}
+				errs[k] = fmt.Errorf("%w:%s", cache.ErrNotGet, err)
+				continue
+			}
+
+			val, err := r.decode(keys[idx], res[j])
+			if err != nil {
+				if errs == nil {
+					errs = map[K]error{}
+				}
+				errs[k] = err
+				continue
+			}
+			vals[k] = val
+		}
+	}
+	return vals, errs
+}
+
+// SetMany stores many items to a redis server with the same ttl, pipelining a SET with PX for each of them
+func (r *Redis[K, V]) SetMany(ctx context.Context, items map[K]V, ttl time.Duration) map[K]error {
+	var errs map[K]error
+	setErr := func(k K, err error) {
+		if errs == nil {
+			errs = map[K]error{}
+		}
+		errs[k] = fmt.Errorf("%w:%s", cache.ErrNotSet, err)
+	}
+
+	pipe := r.cl.Pipeline()
+	cmds := make(map[K]*redis.StatusCmd, len(items))
+	for k, v := range items {
+		key, err := r.key(k)
+		if err != nil {
+			setErr(k, err)
+			continue
+		}
+
+		var val interface{} = v
+		if r.shouldEncodeDecode {
+			data, err := r.enc(key, v)
+			if err != nil {
+				setErr(k, err)
+				continue
+			}
+			val = data
+		}
+		cmds[k] = pipe.Set(ctx, key, val, ttl)
+	}
+
+	if len(cmds) == 0 {
+		return errs
+	}
+
+	// the error of the pipeline is the one of its first failed command, so the commands are checked one by one
+	_, _ = pipe.Exec(ctx)
+	for k, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			setErr(k, err)
+		}
+	}
+	r.stats.Sets.Add(len(items) - len(errs))
+	return errs
+}
+
+// DeleteMany removes many items from a redis server with a single DEL,
+// or with a pipeline of DEL by hash slot on a cluster and by key on a ring
+func (r *Redis[K, V]) DeleteMany(ctx context.Context, ks []K) map[K]error {
+	keys, ks, errs := r.encodeKeys(ks, cache.ErrNotDelete)
+	if len(keys) == 0 {
+		return errs
+	}
+
+	gs := groups(r.cl, keys)
+	pipe := r.cl.Pipeline()
+	cmds := make([]*redis.IntCmd, len(gs))
+	for i, g := range gs {
+		gKeys := make([]string, len(g))
+		for j, idx := range g {
+			gKeys[j] = keys[idx]
+		}
+		cmds[i] = pipe.Del(ctx, gKeys...)
+	}
+	_, _ = pipe.Exec(ctx)
+
+	deleted := len(keys)
+	for i, g := range gs {
+		if err := cmds[i].Err(); err != nil {
+			if errs == nil {
+				errs = map[K]error{}
+			}
+			for _, idx := range g {
+				errs[ks[idx]] = fmt.Errorf("%w:%s", cache.ErrNotDelete, err)
+			}
+			deleted -= len(g)
+		}
+	}
+	r.stats.Deletes.Add(deleted)
+	return errs
+}
+
+// encodeKeys returns the redis keys of the keys that could be encoded, together with them,
+// and the errors of the ones that could not, wrapping the given error
+func (r *Redis[K, V]) encodeKeys(ks []K, wrap error) ([]string, []K, map[K]error) {
+	keys := make([]string, 0, len(ks))
+	encoded := make([]K, 0, len(ks))
+	var errs map[K]error
+	for _, k := range ks {
+		key, err := r.key(k)
+		if err != nil {
+			if errs == nil {
+				errs = map[K]error{}
+			}
+			errs[k] = fmt.Errorf("%w:%s", wrap, err)
+			continue
+		}
+		keys = append(keys, key)
+		encoded = append(encoded, k)
+	}
+	return keys, encoded, errs
+}
+
+// decode returns the value of a MGET reply for the given redis key
+func (r *Redis[K, V]) decode(key string, reply interface{}) (V, error) {
+	data, ok := reply.(string)
+	if !ok {
+		return *new(V), fmt.Errorf("%w:%s", cache.ErrNotFound, redis.Nil)
+	}
+
+	val := new(V)
+	switch r.shouldEncodeDecode {
+	case false:
+		if err := redis.NewStringResult(data, nil).Scan(val); err != nil {
+			return *new(V), fmt.Errorf("%w:%s", cache.ErrNotGet, err)
+		}
+	default:
+		if err := r.dec(key, []byte(data), val); err != nil {
+			return *new(V), decodeError(err)
+		}
+	}
+	return *val, nil
+}
+
 // Delete removes an item from a redis server
 func (r *Redis[K, V]) Delete(ctx context.Context, k K) error {
-	if err := r.cl.Del(ctx, string(k)).Err(); err != nil {
+	key, err := r.key(k)
+	if err != nil {
+		return fmt.Errorf("%w:%s", cache.ErrNotDelete, err)
+	}
+
+	if err := r.cl.Del(ctx, key).Err(); err != nil {
 		return fmt.Errorf("%w:%s", cache.ErrNotDelete, err)
 	}
+	r.stats.Deletes.Inc()
 	return nil
 }
+
+// TTL returns the ttl left to an item in a redis server
+func (r *Redis[K, V]) TTL(ctx context.Context, k K) (time.Duration, error) {
+	key, err := r.key(k)
+	if err != nil {
+		return 0, fmt.Errorf("%w:%s", cache.ErrNotGet, err)
+	}
+
+	ttl, err := r.cl.PTTL(ctx, key).Result()
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("%w:%s", cache.ErrNotGet, err)
+	case ttl == -2:
+		return 0, cache.ErrNotFound
+	case ttl == -1:
+		return cache.NoExpiration, nil
+	case ttl <= 0:
+		return 0, cache.ErrExpired
+	}
+	return ttl, nil
+}
+
+// Stats returns the statistics of the operations made by the cache
+// Redis removes the expired items on its own, so they are counted as misses, while the evictions and the size are unknown
+func (r *Redis[K, V]) Stats() cache.Stats {
+	return cache.Stats{
+		Hits:    r.stats.Hits.Load(),
+		Misses:  r.stats.Misses.Load(),
+		Errors:  r.stats.Errors.Load(),
+		Sets:    r.stats.Sets.Load(),
+		Deletes: r.stats.Deletes.Load(),
+		Size:    -1,
+	}
+}
+
+// recordGet counts the outcome of a read
+func (r *Redis[K, V]) recordGet(err error) {
+	switch {
+	case err == nil:
+		r.stats.Hits.Inc()
+	case errors.Is(err, cache.ErrNotFound):
+		r.stats.Misses.Inc()
+	default:
+		r.stats.Errors.Inc()
+	}
+}
+
+// decodeError returns the error of a decoder as a cache.ErrNotGet, keeping the ones which already are as they are,
+// such as a DecodeError or the DecryptError of the codec package
+func decodeError(err error) error {
+	if errors.Is(err, cache.ErrNotGet) {
+		return err
+	}
+	return fmt.Errorf("%w:%s", cache.ErrNotGet, err)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -41,9 +42,120 @@ func TestRedis(t *testing.T) {
 			EncodeDecodeOption[string, string](DefaultEncoder[string], DefaultDecoder[*string]),
 		),
 	)
+
+	testHelper(
+		t,
+		New[string, string](
+			redis.NewClient(options),
+			KeyedEncodeDecodeOption[string, string](
+				func(key string, val string) ([]byte, error) { return DefaultEncoder[string](key + "=" + val) },
+				func(key string, data []byte, val *string) error {
+					if err := DefaultDecoder[*string](data, val); err != nil {
+						return err
+					}
+					if !strings.HasPrefix(*val, key+"=") {
+						return fmt.Errorf("%w:%s", cache.ErrNotGet, "item bound to another key")
+					}
+					*val = strings.TrimPrefix(*val, key+"=")
+					return nil
+				},
+			),
+		),
+	)
+
+	t.Run("namespace keys and purge them", func(t *testing.T) {
+		ns := uuid.New().String()
+		v1 := New[string, string](redis.NewClient(options), NamespaceOption[string, string](ns), NamespaceVersionOption[string, string]("1"))
+		v2 := New[string, string](redis.NewClient(options), NamespaceOption[string, string](ns), NamespaceVersionOption[string, string]("2"))
+		nested := New[string, string](redis.NewClient(options), NamespaceOption[string, string](ns+":admin"), NamespaceVersionOption[string, string]("1"))
+		raw := New[string, string](redis.NewClient(options))
+
+		for i := 0; i < 2_500; i++ {
+			if err := v1.Set(context.Background(), fmt.Sprint(i), "value", time.Minute); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		if got, err := raw.Get(context.Background(), ns+":1:0"); err != nil || got != "value" {
+			t.Errorf("could not get namespaced item, got: %s %v", got, err)
+		}
+
+		if _, err := v2.Get(context.Background(), "0"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("could not match not found error on the bumped version. got: %s", err)
+		}
+
+		if err := raw.Set(context.Background(), ns+"-other", "value", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+		if err := v2.Set(context.Background(), "0", "value", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+		if err := nested.Set(context.Background(), "0", "value", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if err := v1.Purge(context.Background()); err != nil {
+			t.Fatalf("could not purge namespace: %s", err)
+		}
+
+		if _, err := v1.Get(context.Background(), "2499"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("could not match not found error after purge. got: %s", err)
+		}
+
+		if _, err := raw.Get(context.Background(), ns+"-other"); err != nil {
+			t.Errorf("could not get item outside the namespace: %s", err)
+		}
+
+		if _, err := v2.Get(context.Background(), "0"); err != nil {
+			t.Errorf("could not get item of another version: %s", err)
+		}
+
+		if _, err := nested.Get(context.Background(), "0"); err != nil {
+			t.Errorf("could not get item of a nested namespace: %s", err)
+		}
+
+		if err := raw.Purge(context.Background()); !errors.Is(err, cache.ErrNotDelete) {
+			t.Errorf("could not match not delete error without namespace. got: %s", err)
+		}
+	})
+
+	t.Run("report stats", func(t *testing.T) {
+		redisCache := New[string, string](redis.NewClient(options))
+
+		k := uuid.New().String()
+		_ = redisCache.Set(context.Background(), k, "value", time.Minute)
+		_, _ = redisCache.Get(context.Background(), k)
+		_, _ = redisCache.Get(context.Background(), uuid.New().String())
+		_, _ = redisCache.GetMany(context.Background(), []string{k, uuid.New().String()})
+		_ = redisCache.Delete(context.Background(), k)
+
+		got := redisCache.Stats()
+		if got.Hits != 2 || got.Misses != 2 || got.Sets != 1 || got.Deletes != 1 || got.Size != -1 {
+			t.Errorf("could not match stats, got: %+v", got)
+		}
+	})
+
+	t.Run("encode non string keys", func(t *testing.T) {
+		intCache := New[int64, string](redis.NewClient(options))
+		strCache := New[string, string](redis.NewClient(options))
+
+		k := time.Now().UnixNano()
+		if err := intCache.Set(context.Background(), k, "value", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		got, err := strCache.Get(context.Background(), fmt.Sprint(k))
+		if err != nil {
+			t.Fatalf("could not get item by its encoded key: %s", err)
+		}
+
+		if got != "value" {
+			t.Errorf("could not match value, got: %s. want:%s", got, "value")
+		}
+	})
 }
 
-func testHelper(t *testing.T, redisCache *Redis[string, string]) {
+func testHelper(t *testing.T, redisCache cache.Cache[string, string]) {
 	t.Helper()
 	t.Run("not found", func(t *testing.T) {
 		val, err := redisCache.Get(context.Background(), uuid.New().String())
@@ -111,6 +223,80 @@ func testHelper(t *testing.T, redisCache *Redis[string, string]) {
 		wg.Wait()
 	})
 
+	t.Run("get or load value", func(t *testing.T) {
+		loader := cache.NewLoader[string, string](redisCache)
+
+		var k = uuid.New().String()
+		var calls int
+		load := func(context.Context) (string, time.Duration, error) {
+			calls++
+			return "value", time.Minute, nil
+		}
+
+		for i := 0; i < 2; i++ {
+			got, err := loader.GetOrLoad(context.Background(), k, load)
+			if err != nil {
+				t.Fatalf("could not load item: %s", err)
+			}
+
+			if got != "value" {
+				t.Errorf("could not match value, got: %s. want:%s", got, "value")
+			}
+		}
+
+		if calls != 1 {
+			t.Errorf("could not match load calls, got: %d", calls)
+		}
+	})
+
+	t.Run("set, get and delete many", func(t *testing.T) {
+		one, two, three := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		if errs := cache.SetMany(context.Background(), redisCache, map[string]string{one: "1", two: "2"}, time.Minute); len(errs) != 0 {
+			t.Fatalf("could not set items: %v", errs)
+		}
+
+		vals, errs := cache.GetMany(context.Background(), redisCache, []string{one, two, three})
+		if len(vals) != 2 || vals[one] != "1" || vals[two] != "2" {
+			t.Errorf("could not match values, got: %v", vals)
+		}
+		if len(errs) != 1 || !errors.Is(errs[three], cache.ErrNotFound) {
+			t.Errorf("could not match errors, got: %v", errs)
+		}
+
+		if errs := cache.DeleteMany(context.Background(), redisCache, []string{one, two}); len(errs) != 0 {
+			t.Fatalf("could not delete items: %v", errs)
+		}
+
+		if vals, _ := cache.GetMany(context.Background(), redisCache, []string{one, two}); len(vals) != 0 {
+			t.Errorf("could not match values after delete, got: %v", vals)
+		}
+	})
+
+	t.Run("get ttl left", func(t *testing.T) {
+		ttler, ok := redisCache.(cache.TTLer[string])
+		if !ok {
+			t.Skip("cache does not tell the ttl left")
+		}
+
+		var k = uuid.New().String()
+		if err := redisCache.Set(context.Background(), k, "value", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		ttl, err := ttler.TTL(context.Background(), k)
+		if err != nil {
+			t.Fatalf("could not get ttl: %s", err)
+		}
+
+		if ttl <= 0 || ttl > time.Minute {
+			t.Errorf("could not match ttl, got: %s", ttl)
+		}
+
+		if _, err := ttler.TTL(context.Background(), uuid.New().String()); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("could not match not found error. got: %s", err)
+		}
+	})
+
 	t.Run("get expired value", func(t *testing.T) {
 		var k = uuid.New().String()
 		want := "value"
@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"strings"
+
+	"github.com/go-redis/redis/v9"
+)
+
+// slots is the number of hash slots of a redis cluster
+const slots = 16384
+
+// slot returns the hash slot of a key, hashing only its hashtag when it has one
+func slot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % slots)
+}
+
+// crc16 is the CRC-16/XMODEM checksum used by redis cluster
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// groups splits the indexes of the keys in groups which a multi-key command can handle:
+// a single group on a standalone server, a group by hash slot on a cluster,
+// and a group by key on a ring, as its sharding is not exposed
+func groups(cl redis.Cmdable, keys []string) [][]int {
+	switch cl.(type) {
+	case *redis.ClusterClient:
+		bySlot := map[int][]int{}
+		var order []int
+		for i, k := range keys {
+			s := slot(k)
+			if _, ok := bySlot[s]; !ok {
+				order = append(order, s)
+			}
+			bySlot[s] = append(bySlot[s], i)
+		}
+
+		gs := make([][]int, len(order))
+		for i, s := range order {
+			gs[i] = bySlot[s]
+		}
+		return gs
+	case *redis.Ring:
+		gs := make([][]int, len(keys))
+		for i := range keys {
+			gs[i] = []int{i}
+		}
+		return gs
+	default:
+		all := make([]int, len(keys))
+		for i := range keys {
+			all[i] = i
+		}
+		return [][]int{all}
+	}
+}
@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+)
+
+func TestSlot(t *testing.T) {
+	if got := crc16("123456789"); got != 0x31C3 {
+		t.Errorf("could not match checksum, got: %x. want: %x", got, 0x31C3)
+	}
+
+	tests := map[string]int{
+		"foo":                12182,
+		"bar":                5061,
+		"{foo}.bar":          12182,
+		"prefix:{foo}:{bar}": 12182,
+	}
+	for key, want := range tests {
+		if got := slot(key); got != want {
+			t.Errorf("could not match slot of %s, got: %d. want: %d", key, got, want)
+		}
+	}
+
+	if got, want := slot("{}foo"), int(crc16("{}foo")%slots); got != want {
+		t.Errorf("could not match slot of an empty hashtag, got: %d. want: %d", got, want)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	keys := []string{"{a}1", "{b}1", "{a}2"}
+
+	t.Run("single group on a standalone server", func(t *testing.T) {
+		gs := groups(redis.NewClient(&redis.Options{}), keys)
+		if len(gs) != 1 || len(gs[0]) != 3 {
+			t.Errorf("could not match groups, got: %v", gs)
+		}
+	})
+
+	t.Run("group by slot on a cluster", func(t *testing.T) {
+		gs := groups(redis.NewClusterClient(&redis.ClusterOptions{}), keys)
+		if len(gs) != 2 || len(gs[0]) != 2 || gs[0][1] != 2 || len(gs[1]) != 1 {
+			t.Errorf("could not match groups, got: %v", gs)
+		}
+	})
+
+	t.Run("group by key on a ring", func(t *testing.T) {
+		gs := groups(redis.NewRing(&redis.RingOptions{}), keys)
+		if len(gs) != 3 {
+			t.Errorf("could not match groups, got: %v", gs)
+		}
+	})
+}
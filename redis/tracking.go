@@ -0,0 +1,389 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+
+	"github.com/damianopetrungaro/go-cache"
+)
+
+var _ cache.Cache[string, string] = &Tracking[string, string]{}
+
+// invalidationChannel is the channel where redis publishes the invalidations of the tracked keys
+const invalidationChannel = "__redis__:invalidate"
+
+// TrackingOption represents a function which applies changes to a Tracking cache instance
+type TrackingOption[K comparable, V any] func(*Tracking[K, V])
+
+// TrackingBroadcastOption represents a TrackingOption which enables the broadcast mode,
+// where every key starting with one of the given prefixes is invalidated, read or not.
+// The redis keys not starting with any of the prefixes are never stored locally, as they are not invalidated.
+// Without prefixes every key is invalidated
+func TrackingBroadcastOption[K comparable, V any](prefixes ...string) TrackingOption[K, V] {
+	return func(t *Tracking[K, V]) {
+		t.bcast = true
+		t.prefixes = prefixes
+	}
+}
+
+// TrackingLocalTTLOption represents a TrackingOption which bounds the time an item is kept in the local cache,
+// as a safety net against lost invalidations. It defaults to a minute
+func TrackingLocalTTLOption[K comparable, V any](ttl time.Duration) TrackingOption[K, V] {
+	return func(t *Tracking[K, V]) {
+		t.ttl = ttl
+	}
+}
+
+// TrackingRedisOption represents a TrackingOption which applies the given options to the Redis cache reading the items
+func TrackingRedisOption[K comparable, V any](opts ...Option[K, V]) TrackingOption[K, V] {
+	return func(t *Tracking[K, V]) {
+		t.redisOpts = append(t.redisOpts, opts...)
+	}
+}
+
+// TrackingInMemOption represents a TrackingOption which applies the given options to the local cache.InMem
+// The local cache is keyed by the encoded redis keys
+func TrackingInMemOption[K comparable, V any](opts ...cache.InMemOption[string, V]) TrackingOption[K, V] {
+	return func(t *Tracking[K, V]) {
+		t.inMemOpts = append(t.inMemOpts, opts...)
+	}
+}
+
+// Tracking is a cache.Cache implementation which keeps the items read from a redis server in a local cache.InMem,
+// relying on the server assisted client side caching of redis 6+ to remove them once they change.
+// The invalidations are received in the RESP2 redirect mode, through a dedicated connection.
+// Whenever an invalidation may have been lost, such as on reconnects, the local cache is flushed.
+// An item changed by another client may still be read from the local cache until its invalidation is received
+// It is concurrent safe
+type Tracking[K comparable, V any] struct {
+	opt       redis.Options
+	bcast     bool
+	prefixes  []string
+	ttl       time.Duration
+	redisOpts []Option[K, V]
+	inMemOpts []cache.InMemOption[string, V]
+	cleanUp   time.Duration
+	cap       int
+
+	sub *redis.Client
+	ps  *redis.PubSub
+	wg  sync.WaitGroup
+
+	mu       sync.RWMutex
+	redirect int64
+	cl       *redis.Client
+	remote   *Redis[K, V]
+	local    *cache.InMem[string, V]
+	// gen changes on every invalidation, so that an item read while being invalidated is not kept locally
+	gen    uint64
+	closed bool
+}
+
+// NewTracking returns a Tracking connecting with the given options, whose local cache.InMem
+// is created with the given clean up interval and capacity
+func NewTracking[K comparable, V any](
+	ctx context.Context,
+	opt *redis.Options,
+	cleanUpInterval time.Duration,
+	cap int,
+	opts ...TrackingOption[K, V],
+) (*Tracking[K, V], error) {
+	t := &Tracking[K, V]{
+		opt:     *opt,
+		ttl:     time.Minute,
+		cleanUp: cleanUpInterval,
+		cap:     cap,
+	}
+
+	for _, o := range opts {
+		o(t)
+	}
+
+	t.local = cache.NewInMemory[string, V](t.cleanUp, t.cap, t.inMemOpts...)
+
+	subOpt := t.opt
+	subOpt.OnConnect = t.onSubscriberConnect
+	t.sub = redis.NewClient(&subOpt)
+	t.ps = t.sub.Subscribe(ctx, invalidationChannel)
+	if _, err := t.ps.Receive(ctx); err != nil {
+		_ = t.Close()
+		return nil, fmt.Errorf("could not subscribe to redis invalidations: %w", err)
+	}
+
+	t.wg.Add(1)
+	go t.receive()
+
+	return t, nil
+}
+
+// Get retrieves an item from the local cache, or from the redis server storing it locally
+func (t *Tracking[K, V]) Get(ctx context.Context, k K) (V, error) {
+	t.mu.RLock()
+	local, remote, gen := t.local, t.remote, t.gen
+	t.mu.RUnlock()
+
+	key, err := remote.key(k)
+	if err != nil {
+		return *new(V), fmt.Errorf("%w:%s", cache.ErrNotGet, err)
+	}
+
+	if val, err := local.Get(ctx, key); err == nil {
+		return val, nil
+	}
+
+	val, err := remote.Get(ctx, k)
+	if err != nil || !t.tracked(key) {
+		return val, err
+	}
+
+	// the local cache is never called while holding the lock, so that its OnEvictOption can call back into the Tracking.
+	// The item is stored first, then removed if an invalidation was received meanwhile, as it may be stale:
+	// either this check sees the new generation, or the invalidation removes the item after it got stored
+	_ = local.Set(context.Background(), key, val, t.ttl)
+	t.mu.RLock()
+	stale := t.gen != gen
+	t.mu.RUnlock()
+	if stale {
+		_ = local.Delete(context.Background(), key)
+	}
+
+	return val, nil
+}
+
+// Set stores an item to the redis server, the local cache gets it on the next read
+func (t *Tracking[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
+	t.mu.RLock()
+	remote := t.remote
+	t.mu.RUnlock()
+
+	if err := remote.Set(ctx, k, v, ttl); err != nil {
+		return err
+	}
+
+	// the key was encoded by the redis cache already
+	key, _ := remote.key(k)
+	t.invalidate(key)
+	return nil
+}
+
+// Delete removes an item from the redis server and the local cache
+func (t *Tracking[K, V]) Delete(ctx context.Context, k K) error {
+	t.mu.RLock()
+	remote := t.remote
+	t.mu.RUnlock()
+
+	if err := remote.Delete(ctx, k); err != nil {
+		return err
+	}
+
+	// the key was encoded by the redis cache already
+	key, _ := remote.key(k)
+	t.invalidate(key)
+	return nil
+}
+
+// Close stops receiving invalidations and closes the connections and the local cache
+func (t *Tracking[K, V]) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	cl, local := t.cl, t.local
+	t.mu.Unlock()
+
+	err := t.ps.Close()
+	if sErr := t.sub.Close(); err == nil {
+		err = sErr
+	}
+	t.wg.Wait()
+	if cl != nil {
+		if cErr := cl.Close(); err == nil {
+			err = cErr
+		}
+	}
+	if lErr := local.Close(); err == nil {
+		err = lErr
+	}
+	return err
+}
+
+// receive handles the invalidations until the subscription is closed
+// A message which cannot be read may be an invalidation of all the keys, such as on FLUSHALL, so it flushes the local cache
+func (t *Tracking[K, V]) receive() {
+	defer t.wg.Done()
+
+	ctx := context.Background()
+	var failed bool
+	for {
+		msg, err := t.ps.ReceiveTimeout(ctx, time.Minute)
+		switch {
+		case t.isClosed():
+			return
+		case isTimeout(err):
+			// a ping reconnects the subscription when the connection is broken
+			_ = t.ps.Ping(ctx)
+			continue
+		case err != nil:
+			if failed {
+				time.Sleep(100 * time.Millisecond)
+			}
+			failed = true
+			t.flush()
+			continue
+		}
+
+		failed = false
+
+		if msg, ok := msg.(*redis.Message); ok {
+			for _, k := range msg.PayloadSlice {
+				t.invalidate(k)
+			}
+		}
+	}
+}
+
+// onSubscriberConnect points the tracking of the data connections to the subscription connection
+// On reconnects the data connections are replaced, as their tracking points to the previous one
+func (t *Tracking[K, V]) onSubscriberConnect(ctx context.Context, cn *redis.Conn) error {
+	if t.opt.OnConnect != nil {
+		if err := t.opt.OnConnect(ctx, cn); err != nil {
+			return err
+		}
+	}
+
+	id, err := cn.ClientID(ctx).Result()
+	if err != nil {
+		return err
+	}
+
+	dataOpt := t.opt
+	dataOpt.OnConnect = t.onDataConnect
+	dataOpt.Dialer = t.dialer()
+	cl := redis.NewClient(&dataOpt)
+
+	t.mu.Lock()
+	old := t.cl
+	t.redirect = id
+	t.cl = cl
+	t.remote = New[K, V](cl, t.redisOpts...)
+	t.mu.Unlock()
+
+	if old != nil {
+		_ = old.Close()
+		t.flush()
+	}
+	return nil
+}
+
+// onDataConnect enables the tracking of a data connection
+func (t *Tracking[K, V]) onDataConnect(ctx context.Context, cn *redis.Conn) error {
+	if t.opt.OnConnect != nil {
+		if err := t.opt.OnConnect(ctx, cn); err != nil {
+			return err
+		}
+	}
+
+	t.mu.RLock()
+	args := []interface{}{"CLIENT", "TRACKING", "on", "REDIRECT", t.redirect}
+	t.mu.RUnlock()
+	if t.bcast {
+		args = append(args, "BCAST")
+		for _, p := range t.prefixes {
+			args = append(args, "PREFIX", p)
+		}
+	}
+	return cn.Process(ctx, redis.NewStatusCmd(ctx, args...))
+}
+
+// dialer returns the dialer of the data connections, which flushes the local cache when a connection gets closed
+// as redis stops tracking the keys read through it
+func (t *Tracking[K, V]) dialer() func(context.Context, string, string) (net.Conn, error) {
+	dial := t.opt.Dialer
+	if dial == nil {
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			d := &net.Dialer{Timeout: t.opt.DialTimeout, KeepAlive: 5 * time.Minute}
+			if t.opt.TLSConfig == nil {
+				return d.DialContext(ctx, network, addr)
+			}
+			return tls.DialWithDialer(d, network, addr, t.opt.TLSConfig)
+		}
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		cn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &trackedConn{Conn: cn, onClose: t.flush}, nil
+	}
+}
+
+// invalidate removes the redis key from the local cache
+// The generation changes before the removal, so that a concurrent Get storing the key removes it again
+func (t *Tracking[K, V]) invalidate(k string) {
+	t.mu.Lock()
+	t.gen++
+	local := t.local
+	t.mu.Unlock()
+
+	_ = local.Delete(context.Background(), k)
+}
+
+// flush replaces the local cache with an empty one
+func (t *Tracking[K, V]) flush() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+
+	t.gen++
+	old := t.local
+	t.local = cache.NewInMemory[string, V](t.cleanUp, t.cap, t.inMemOpts...)
+	t.mu.Unlock()
+
+	_ = old.Close()
+}
+
+// tracked reports whether redis invalidates the key, which in broadcast mode must start with one of the prefixes
+func (t *Tracking[K, V]) tracked(key string) bool {
+	if !t.bcast || len(t.prefixes) == 0 {
+		return true
+	}
+
+	for _, p := range t.prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tracking[K, V]) isClosed() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.closed
+}
+
+// trackedConn is a net.Conn calling onClose once closed
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.onClose)
+	return err
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
@@ -0,0 +1,250 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+
+	"github.com/damianopetrungaro/go-cache"
+	. "github.com/damianopetrungaro/go-cache/redis"
+)
+
+func TestTracking(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test")
+	}
+
+	options, err := redis.ParseURL(getRedisUriHelper(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracking, err := NewTracking[string, string](context.Background(), options, time.Minute, 100)
+	if err != nil {
+		t.Fatalf("could not create tracking: %s", err)
+	}
+	t.Cleanup(func() { _ = tracking.Close() })
+
+	testHelper(t, tracking)
+
+	t.Run("drop local item changed by another client", func(t *testing.T) {
+		other := redis.NewClient(options)
+		t.Cleanup(func() { _ = other.Close() })
+
+		var k = uuid.New().String()
+		if err := tracking.Set(context.Background(), k, "old", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if got, err := tracking.Get(context.Background(), k); err != nil || got != "old" {
+			t.Fatalf("could not get item, got: %s %v", got, err)
+		}
+
+		if err := other.Set(context.Background(), k, "new", time.Minute).Err(); err != nil {
+			t.Fatalf("could not set item from another client: %s", err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			got, err := tracking.Get(context.Background(), k)
+			if err != nil {
+				t.Fatalf("could not get item: %s", err)
+			}
+			if got == "new" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("could not match invalidated value, got: %s", got)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if err := other.Del(context.Background(), k).Err(); err != nil {
+			t.Fatalf("could not delete item from another client: %s", err)
+		}
+
+		deadline = time.Now().Add(5 * time.Second)
+		for {
+			_, err := tracking.Get(context.Background(), k)
+			if errors.Is(err, cache.ErrNotFound) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("could not match not found error. got: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+	t.Run("call back into the tracking from the local eviction callback", func(t *testing.T) {
+		evicted := make(chan error, 1)
+		var tr *Tracking[string, string]
+		tr, err := NewTracking[string, string](context.Background(), options, time.Minute, 1, TrackingInMemOption[string, string](
+			cache.OnEvictOption[string, string](func(k, _ string, reason cache.EvictionReason) {
+				if reason != cache.EvictionReasonCapacity {
+					return
+				}
+				select {
+				case evicted <- tr.Delete(context.Background(), k):
+				default:
+				}
+			}),
+		))
+		if err != nil {
+			t.Fatalf("could not create tracking: %s", err)
+		}
+		t.Cleanup(func() { _ = tr.Close() })
+
+		k1, k2 := uuid.New().String(), uuid.New().String()
+		for _, k := range []string{k1, k2} {
+			if err := tr.Set(context.Background(), k, k, time.Minute); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		if _, err := tr.Get(context.Background(), k1); err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+
+		// reading the second item evicts the first one from the local cache, whose callback deletes it
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = tr.Get(context.Background(), k2)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("could not call back into the tracking from the eviction callback")
+		}
+
+		if err := <-evicted; err != nil {
+			t.Fatalf("could not delete evicted item: %s", err)
+		}
+
+		if _, err := tr.Get(context.Background(), k1); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("could not match not found error. got: %v", err)
+		}
+	})
+
+	t.Run("flush the local cache when the subscription reconnects", func(t *testing.T) {
+		tr, err := NewTracking[string, string](context.Background(), options, time.Minute, 100, TrackingLocalTTLOption[string, string](time.Hour))
+		if err != nil {
+			t.Fatalf("could not create tracking: %s", err)
+		}
+		t.Cleanup(func() { _ = tr.Close() })
+
+		other := redis.NewClient(options)
+		t.Cleanup(func() { _ = other.Close() })
+
+		k := uuid.New().String()
+		if err := tr.Set(context.Background(), k, "old", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if got, err := tr.Get(context.Background(), k); err != nil || got != "old" {
+			t.Fatalf("could not get item, got: %s %v", got, err)
+		}
+
+		// the invalidations redirected to the killed connection are lost
+		if err := other.ClientKillByFilter(context.Background(), "TYPE", "pubsub").Err(); err != nil {
+			t.Fatalf("could not kill subscription: %s", err)
+		}
+
+		if err := other.Set(context.Background(), k, "new", time.Minute).Err(); err != nil {
+			t.Fatalf("could not set item from another client: %s", err)
+		}
+
+		waitForValueHelper(t, tr, k, "new")
+	})
+
+	t.Run("flush the local cache when a data connection gets closed", func(t *testing.T) {
+		tr, err := NewTracking[string, string](context.Background(), options, time.Minute, 100, TrackingLocalTTLOption[string, string](time.Hour))
+		if err != nil {
+			t.Fatalf("could not create tracking: %s", err)
+		}
+		t.Cleanup(func() { _ = tr.Close() })
+
+		other := redis.NewClient(options)
+		t.Cleanup(func() { _ = other.Close() })
+
+		k := uuid.New().String()
+		if err := tr.Set(context.Background(), k, "old", time.Minute); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if got, err := tr.Get(context.Background(), k); err != nil || got != "old" {
+			t.Fatalf("could not get item, got: %s %v", got, err)
+		}
+
+		// redis stops tracking the keys read by the killed connections, which the client notices on their next use
+		if err := other.ClientKillByFilter(context.Background(), "TYPE", "normal").Err(); err != nil {
+			t.Fatalf("could not kill data connections: %s", err)
+		}
+
+		if err := other.Set(context.Background(), k, "new", time.Minute).Err(); err != nil {
+			t.Fatalf("could not set item from another client: %s", err)
+		}
+
+		_, _ = tr.Get(context.Background(), uuid.New().String())
+		waitForValueHelper(t, tr, k, "new")
+	})
+
+	t.Run("store locally the keys of the broadcast prefixes only", func(t *testing.T) {
+		prefix := uuid.New().String() + ":"
+		tr, err := NewTracking[string, string](context.Background(), options, time.Minute, 100, TrackingBroadcastOption[string, string](prefix))
+		if err != nil {
+			t.Fatalf("could not create tracking: %s", err)
+		}
+		t.Cleanup(func() { _ = tr.Close() })
+
+		other := redis.NewClient(options)
+		t.Cleanup(func() { _ = other.Close() })
+
+		prefixed, unprefixed := prefix+"key", uuid.New().String()
+		for _, k := range []string{prefixed, unprefixed} {
+			if err := tr.Set(context.Background(), k, "old", time.Minute); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+
+			if got, err := tr.Get(context.Background(), k); err != nil || got != "old" {
+				t.Fatalf("could not get item, got: %s %v", got, err)
+			}
+
+			if err := other.Set(context.Background(), k, "new", time.Minute).Err(); err != nil {
+				t.Fatalf("could not set item from another client: %s", err)
+			}
+		}
+
+		// the key out of the prefixes is not invalidated, so it is read from redis every time
+		if got, err := tr.Get(context.Background(), unprefixed); err != nil || got != "new" {
+			t.Errorf("could not get unprefixed item from redis, got: %s %v", got, err)
+		}
+
+		waitForValueHelper(t, tr, prefixed, "new")
+	})
+}
+
+// waitForValueHelper waits until the tracking returns the given value for the key
+func waitForValueHelper(t *testing.T, tr *Tracking[string, string], k, want string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := tr.Get(context.Background(), k)
+		if err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("could not match value, got: %s. want: %s", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Cache[string, any] = &Refresher[string, any]{}
+
+// StaleEntry is the item stored by a Refresher, carrying the time after which its value is stale
+// Its fields are exported so that it can be serialized by remote caches
+type StaleEntry[V any] struct {
+	Value   V     `json:"value"`
+	StaleAt int64 `json:"stale_at"`
+}
+
+// RefreshFunc represents a function loading the fresh value of a key
+type RefreshFunc[K comparable, V any] func(context.Context, K) (V, error)
+
+// RefresherOption represents a function which applies changes to a Refresher cache instance
+type RefresherOption[K comparable, V any] func(*Refresher[K, V])
+
+// RefreshAheadOption represents a RefresherOption which refreshes a read value when it is about to become stale
+// A value read within the given window before its soft ttl is returned and refreshed in the background
+func RefreshAheadOption[K comparable, V any](window time.Duration) RefresherOption[K, V] {
+	return func(r *Refresher[K, V]) {
+		r.ahead = window
+	}
+}
+
+// MaxRefreshesOption represents a RefresherOption which bounds the refreshes running in the background
+// When the bound is reached, stale values are returned without triggering a refresh. A non-positive max is clamped to 1
+func MaxRefreshesOption[K comparable, V any](max int) RefresherOption[K, V] {
+	if max <= 0 {
+		max = 1
+	}
+
+	return func(r *Refresher[K, V]) {
+		r.sem = make(chan struct{}, max)
+	}
+}
+
+// RefresherClockOption represents a RefresherOption which uses the given Clock to tell when a value is stale
+func RefresherClockOption[K comparable, V any](c Clock) RefresherOption[K, V] {
+	return func(r *Refresher[K, V]) {
+		r.clock = c
+	}
+}
+
+// Refresher is a Cache implementation which serves stale values while refreshing them in the background
+// A value is fresh until its soft ttl, then it is returned stale triggering a single refresh until its hard ttl,
+// after which it is missing and gets loaded before being returned
+// It is concurrent safe
+type Refresher[K comparable, V any] struct {
+	cache      Cache[K, StaleEntry[V]]
+	loader     *Loader[K, StaleEntry[V]]
+	refresh    RefreshFunc[K, V]
+	softTTL    time.Duration
+	hardTTL    time.Duration
+	ahead      time.Duration
+	clock      Clock
+	sem        chan struct{}
+	mu         sync.Mutex
+	refreshing map[K]struct{}
+	wg         sync.WaitGroup
+}
+
+// NewRefresher returns a Refresher storing the values in the given Cache
+func NewRefresher[K comparable, V any](
+	c Cache[K, StaleEntry[V]],
+	refresh RefreshFunc[K, V],
+	softTTL time.Duration,
+	hardTTL time.Duration,
+	opts ...RefresherOption[K, V],
+) *Refresher[K, V] {
+	r := &Refresher[K, V]{
+		cache:      c,
+		loader:     NewLoader[K, StaleEntry[V]](c),
+		refresh:    refresh,
+		softTTL:    softTTL,
+		hardTTL:    hardTTL,
+		clock:      systemClock{},
+		sem:        make(chan struct{}, 10),
+		refreshing: map[K]struct{}{},
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+// Get retrieves an item from the wrapped cache, loading it when missing
+// A stale item, or one about to become stale when refreshing ahead, is returned and refreshed in the background
+func (r *Refresher[K, V]) Get(ctx context.Context, k K) (V, error) {
+	entry, err := r.loader.GetOrLoad(ctx, k, func(ctx context.Context) (StaleEntry[V], time.Duration, error) {
+		val, err := r.refresh(ctx, k)
+		return r.entry(val, r.hardTTL), r.hardTTL, err
+	})
+	if err != nil {
+		return *new(V), err
+	}
+
+	if r.clock.Now().UnixNano() >= entry.StaleAt-int64(r.ahead) {
+		r.refreshAsync(ctx, k)
+	}
+
+	return entry.Value, nil
+}
+
+// Set stores an item to the wrapped cache, the ttl is used as hard ttl
+func (r *Refresher[K, V]) Set(ctx context.Context, k K, v V, ttl time.Duration) error {
+	return r.cache.Set(ctx, k, r.entry(v, ttl), ttl)
+}
+
+// Delete removes an item from the wrapped cache
+func (r *Refresher[K, V]) Delete(ctx context.Context, k K) error {
+	return r.cache.Delete(ctx, k)
+}
+
+// Close waits for the refreshes running in the background
+func (r *Refresher[K, V]) Close() error {
+	r.wg.Wait()
+	return nil
+}
+
+// entry returns a StaleEntry becoming stale after the soft ttl, or the hard one when shorter
+func (r *Refresher[K, V]) entry(v V, hardTTL time.Duration) StaleEntry[V] {
+	ttl := r.softTTL
+	if hardTTL != NoExpiration && hardTTL < ttl {
+		ttl = hardTTL
+	}
+	return StaleEntry[V]{Value: v, StaleAt: r.clock.Now().Add(ttl).UnixNano()}
+}
+
+// refreshAsync refreshes the key in the background, unless a refresh of it is already running or the bound is reached
+func (r *Refresher[K, V]) refreshAsync(ctx context.Context, k K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.refreshing[k]; ok {
+		return
+	}
+
+	select {
+	case r.sem <- struct{}{}:
+	default:
+		return
+	}
+
+	r.refreshing[k] = struct{}{}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			r.mu.Lock()
+			delete(r.refreshing, k)
+			r.mu.Unlock()
+			<-r.sem
+		}()
+
+		ctx := detachedContext{Context: ctx}
+		val, err := r.refresh(ctx, k)
+		if err != nil {
+			// the stale value is kept until its hard ttl, the next read tries again
+			return
+		}
+		_ = r.cache.Set(ctx, k, r.entry(val, r.hardTTL), r.hardTTL)
+	}()
+}
@@ -0,0 +1,212 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/cachetest"
+)
+
+func TestRefresher(t *testing.T) {
+	t.Run("load missing value", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		var calls int64
+		refresher := newRefresherHelper(t, clock, func(_ context.Context, k string) (string, error) {
+			return fmt.Sprintf("%s %d", k, atomic.AddInt64(&calls, 1)), nil
+		})
+
+		got, err := refresher.Get(context.Background(), "key")
+		if err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+
+		if got != "key 1" {
+			t.Errorf("could not match value, got: %s. want:%s", got, "key 1")
+		}
+
+		clock.Advance(time.Second)
+		if got, _ := refresher.Get(context.Background(), "key"); got != "key 1" {
+			t.Errorf("could not match fresh value, got: %s. want:%s", got, "key 1")
+		}
+
+		if err := refresher.Close(); err != nil {
+			t.Fatalf("could not close refresher: %s", err)
+		}
+
+		if calls != 1 {
+			t.Errorf("could not match refresh calls, got: %d", calls)
+		}
+	})
+
+	t.Run("serve stale value while refreshing", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		var calls int64
+		release := make(chan struct{})
+		refresher := newRefresherHelper(t, clock, func(_ context.Context, k string) (string, error) {
+			if n := atomic.AddInt64(&calls, 1); n > 1 {
+				<-release
+				return "fresh value", nil
+			}
+			return "stale value", nil
+		})
+
+		if got, _ := refresher.Get(context.Background(), "key"); got != "stale value" {
+			t.Fatalf("could not match value, got: %s", got)
+		}
+
+		clock.Advance(2 * time.Minute)
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got, _ := refresher.Get(context.Background(), "key"); got != "stale value" {
+					t.Errorf("could not match stale value, got: %s", got)
+				}
+			}()
+		}
+		wg.Wait()
+
+		close(release)
+		if err := refresher.Close(); err != nil {
+			t.Fatalf("could not close refresher: %s", err)
+		}
+
+		if got, _ := refresher.Get(context.Background(), "key"); got != "fresh value" {
+			t.Errorf("could not match refreshed value, got: %s", got)
+		}
+
+		if calls != 2 {
+			t.Errorf("could not match refresh calls, got: %d", calls)
+		}
+	})
+
+	t.Run("load value after hard ttl", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		var calls int64
+		refresher := newRefresherHelper(t, clock, func(_ context.Context, k string) (string, error) {
+			return fmt.Sprintf("%s %d", k, atomic.AddInt64(&calls, 1)), nil
+		})
+
+		_, _ = refresher.Get(context.Background(), "key")
+		clock.Advance(time.Hour)
+
+		if got, _ := refresher.Get(context.Background(), "key"); got != "key 2" {
+			t.Errorf("could not match loaded value, got: %s", got)
+		}
+	})
+
+	t.Run("refresh ahead of soft ttl", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		var calls int64
+		refresher := newRefresherHelper(t, clock, func(_ context.Context, k string) (string, error) {
+			return fmt.Sprintf("%s %d", k, atomic.AddInt64(&calls, 1)), nil
+		}, RefreshAheadOption[string, string](10*time.Second))
+
+		_, _ = refresher.Get(context.Background(), "key")
+		clock.Advance(55 * time.Second)
+
+		if got, _ := refresher.Get(context.Background(), "key"); got != "key 1" {
+			t.Errorf("could not match value, got: %s", got)
+		}
+
+		if err := refresher.Close(); err != nil {
+			t.Fatalf("could not close refresher: %s", err)
+		}
+
+		if got, _ := refresher.Get(context.Background(), "key"); got != "key 2" {
+			t.Errorf("could not match refreshed value, got: %s", got)
+		}
+	})
+
+	t.Run("read the cache once on miss", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		inmem := NewInMemory[string, StaleEntry[string]](time.Second, 10, ClockOption[string, StaleEntry[string]](clock))
+		t.Cleanup(func() { _ = inmem.Close() })
+		counting := &readCountingCache{Cache: inmem}
+		refresher := NewRefresher[string, string](counting, func(_ context.Context, k string) (string, error) {
+			return k, nil
+		}, time.Minute, 10*time.Minute, RefresherClockOption[string, string](clock))
+		t.Cleanup(func() { _ = refresher.Close() })
+
+		if _, err := refresher.Get(context.Background(), "key"); err != nil {
+			t.Fatalf("could not get item: %s", err)
+		}
+
+		if got := atomic.LoadInt64(&counting.gets); got != 1 {
+			t.Errorf("could not match cache reads, got: %d", got)
+		}
+	})
+
+	maxes := map[string]int{
+		"bound background refreshes":                  1,
+		"clamp non-positive max background refreshes": 0,
+	}
+	for name, max := range maxes {
+		max := max
+		t.Run(name, func(t *testing.T) {
+			clock := cachetest.NewClock(time.Now())
+			var calls int64
+			release := make(chan struct{})
+			refresher := newRefresherHelper(t, clock, func(_ context.Context, k string) (string, error) {
+				if n := atomic.AddInt64(&calls, 1); n > 2 {
+					<-release
+				}
+				return k, nil
+			}, MaxRefreshesOption[string, string](max))
+
+			_, _ = refresher.Get(context.Background(), "one")
+			_, _ = refresher.Get(context.Background(), "two")
+			clock.Advance(2 * time.Minute)
+
+			_, _ = refresher.Get(context.Background(), "one")
+			_, _ = refresher.Get(context.Background(), "two")
+			close(release)
+
+			if err := refresher.Close(); err != nil {
+				t.Fatalf("could not close refresher: %s", err)
+			}
+
+			if calls != 3 {
+				t.Errorf("could not match refresh calls, got: %d", calls)
+			}
+		})
+	}
+}
+
+// readCountingCache is a Cache counting its reads
+type readCountingCache struct {
+	Cache[string, StaleEntry[string]]
+	gets int64
+}
+
+func (c *readCountingCache) Get(ctx context.Context, k string) (StaleEntry[string], error) {
+	atomic.AddInt64(&c.gets, 1)
+	return c.Cache.Get(ctx, k)
+}
+
+func newRefresherHelper(
+	t *testing.T,
+	clock *cachetest.Clock,
+	refresh RefreshFunc[string, string],
+	opts ...RefresherOption[string, string],
+) *Refresher[string, string] {
+	t.Helper()
+	inmem := NewInMemory[string, StaleEntry[string]](time.Second, 10, ClockOption[string, StaleEntry[string]](clock))
+	opts = append(opts, RefresherClockOption[string, string](clock))
+	refresher := NewRefresher[string, string](inmem, refresh, time.Minute, 10*time.Minute, opts...)
+	t.Cleanup(func() {
+		if err := refresher.Close(); err != nil {
+			t.Errorf("could not close refresher: %s", err)
+		}
+		if err := inmem.Close(); err != nil {
+			t.Errorf("could not close inmem: %s", err)
+		}
+	})
+	return refresher
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	_ Cache[string, any] = &ShardedInMem[string, any]{}
+	_ TTLer[string]      = &ShardedInMem[string, any]{}
+	_ StatsReporter      = &ShardedInMem[string, any]{}
+)
+
+// ShardedInMem is a Cache implementation which spreads the items across many InMem shards
+// Each shard has its own lock, capacity and clean up, so concurrent operations on different keys do not contend
+// It is concurrent safe
+type ShardedInMem[K comparable, V any] struct {
+	shards []*InMem[K, V]
+	mask   uint64
+}
+
+// NewShardedInMemory returns a ShardedInMem instance
+// the number of shards is rounded up to a power of two, but never exceeds the capacity,
+// which is split between the shards so that their capacities add up to it.
+// The max cost of a CostOption is shared by the shards, each one evicting its own items until it is respected:
+// it can be exceeded when a shard has no other item to evict, until the shards holding the rest of it set a new item.
+// The options are applied to every shard, so an EvictionPolicyFuncOption must be used in place of an EvictionPolicyOption,
+// which panics when shared by more than one shard
+func NewShardedInMemory[K comparable, V any](shards int, cleanUpInterval time.Duration, cap int, opts ...InMemOption[K, V]) *ShardedInMem[K, V] {
+	n := 1
+	for n < shards {
+		n *= 2
+	}
+	for n > 1 && n > cap {
+		n /= 2
+	}
+
+	opts = append(opts[:len(opts):len(opts)], sharedCostOption[K, V](new(int64), n))
+	s := &ShardedInMem[K, V]{
+		shards: make([]*InMem[K, V], n),
+		mask:   uint64(n - 1),
+	}
+	for i := range s.shards {
+		shardCap := cap / n
+		if i < cap%n {
+			shardCap++
+		}
+		s.shards[i] = NewInMemory[K, V](cleanUpInterval, shardCap, opts...)
+	}
+
+	return s
+}
+
+// Get retrieves an item from the shard owning the key
+func (s *ShardedInMem[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return s.shard(key).Get(ctx, key)
+}
+
+// Set stores an item to the shard owning the key
+func (s *ShardedInMem[K, V]) Set(ctx context.Context, key K, val V, ttl time.Duration) error {
+	return s.shard(key).Set(ctx, key, val, ttl)
+}
+
+// Delete removes an item from the shard owning the key
+func (s *ShardedInMem[K, V]) Delete(ctx context.Context, key K) error {
+	return s.shard(key).Delete(ctx, key)
+}
+
+// TTL returns the ttl left to an item of the shard owning the key
+func (s *ShardedInMem[K, V]) TTL(ctx context.Context, key K) (time.Duration, error) {
+	return s.shard(key).TTL(ctx, key)
+}
+
+// Stats returns the statistics of the cache, summing the ones of every shard
+func (s *ShardedInMem[K, V]) Stats() Stats {
+	var stats Stats
+	for _, shard := range s.shards {
+		stats.add(shard.Stats())
+	}
+	return stats
+}
+
+// Close stops the inner ticker of every shard
+func (s *ShardedInMem[K, V]) Close() error {
+	for _, shard := range s.shards {
+		if err := shard.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ShardedInMem[K, V]) shard(key K) *InMem[K, V] {
+	return s.shards[hashKey(key)&s.mask]
+}
@@ -0,0 +1,215 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/damianopetrungaro/go-cache"
+)
+
+func TestShardedInMem(t *testing.T) {
+	t.Run("find set values", func(t *testing.T) {
+		sharded := newShardedInMemHelper(t, 1_000)
+
+		for i := 0; i < 100; i++ {
+			k := fmt.Sprintf("key %d", i)
+			if err := sharded.Set(context.Background(), k, k, NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		for i := 0; i < 100; i++ {
+			k := fmt.Sprintf("key %d", i)
+			got, err := sharded.Get(context.Background(), k)
+			if err != nil {
+				t.Fatalf("could not get item: %s", err)
+			}
+
+			if got != k {
+				t.Errorf("could not match value, got: %s. want:%s", got, k)
+			}
+		}
+	})
+
+	t.Run("delete set value", func(t *testing.T) {
+		sharded := newShardedInMemHelper(t, 1_000)
+
+		const k = "key"
+		if err := sharded.Set(context.Background(), k, "value", NoExpiration); err != nil {
+			t.Fatalf("could not set item: %s", err)
+		}
+
+		if err := sharded.Delete(context.Background(), k); err != nil {
+			t.Fatalf("could not delete item: %s", err)
+		}
+
+		val, err := sharded.Get(context.Background(), k)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("could not match not found error. got: %s", err)
+		}
+
+		if val != "" {
+			t.Errorf("could not match default value, got: %s", val)
+		}
+	})
+
+	t.Run("ensure capacity per shard", func(t *testing.T) {
+		sharded := newShardedInMemHelper(t, 16, LRUOption[string, string]())
+
+		for i := 0; i < 1_000; i++ {
+			k := fmt.Sprintf("key %d", i)
+			if err := sharded.Set(context.Background(), k, k, NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		var found int
+		for i := 0; i < 1_000; i++ {
+			if _, err := sharded.Get(context.Background(), fmt.Sprintf("key %d", i)); err == nil {
+				found++
+			}
+		}
+
+		if found > 16 {
+			t.Errorf("could not match capacity, got: %d", found)
+		}
+	})
+
+	t.Run("ensure total capacity", func(t *testing.T) {
+		for _, shards := range []int{8, 64} {
+			sharded := NewShardedInMemory[int, int](shards, time.Minute, 10, LRUOption[int, int]())
+			t.Cleanup(func() { _ = sharded.Close() })
+
+			for i := 0; i < 1_000; i++ {
+				if err := sharded.Set(context.Background(), i, i, NoExpiration); err != nil {
+					t.Fatalf("could not set item: %s", err)
+				}
+			}
+
+			if got := sharded.Stats().Size; got > 10 {
+				t.Errorf("could not match capacity of %d shards, got: %d", shards, got)
+			}
+		}
+	})
+
+	t.Run("share the max cost between shards", func(t *testing.T) {
+		sharded := NewShardedInMemory[int, int](8, time.Minute, 1_000, CostOption[int, int](100, func(v int) int64 {
+			return int64(v)
+		}))
+		t.Cleanup(func() { _ = sharded.Close() })
+
+		if err := sharded.Set(context.Background(), 1, 50, NoExpiration); err != nil {
+			t.Fatalf("could not set item within the max cost: %s", err)
+		}
+
+		if err := sharded.Set(context.Background(), 2, 101, NoExpiration); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("could not match too large error, got: %v", err)
+		}
+
+		for i := 3; i < 1_000; i++ {
+			if err := sharded.Set(context.Background(), i, 10, NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		// each shard keeps at most one item over the max cost
+		if got := sharded.Stats().Size; got > 10+8 {
+			t.Errorf("could not match items within the max cost, got: %d", got)
+		}
+	})
+
+	t.Run("find pointer keys by address", func(t *testing.T) {
+		type pk struct{ id int }
+		sharded := NewShardedInMemory[*pk, string](64, time.Minute, 1_000)
+		t.Cleanup(func() { _ = sharded.Close() })
+
+		keys := make([]*pk, 100)
+		for i := range keys {
+			keys[i] = &pk{id: i}
+			if err := sharded.Set(context.Background(), keys[i], "value", NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+			keys[i].id = -i - 1
+		}
+
+		for i, k := range keys {
+			if _, err := sharded.Get(context.Background(), k); err != nil {
+				t.Errorf("could not get item %d after changing the pointed value: %s", i, err)
+			}
+		}
+
+		if _, err := sharded.Get(context.Background(), &pk{id: -1}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("could not match not found error for an equal pointed value, got: %s", err)
+		}
+	})
+
+	t.Run("find struct keys with signed zeros", func(t *testing.T) {
+		type sk struct {
+			name  string
+			value float64
+		}
+		sharded := NewShardedInMemory[sk, string](64, time.Minute, 1_000)
+		t.Cleanup(func() { _ = sharded.Close() })
+
+		negZero := math.Copysign(0, -1)
+		for i := 0; i < 100; i++ {
+			k := sk{name: fmt.Sprintf("key %d", i), value: negZero}
+			if err := sharded.Set(context.Background(), k, k.name, NoExpiration); err != nil {
+				t.Fatalf("could not set item: %s", err)
+			}
+		}
+
+		for i := 0; i < 100; i++ {
+			if _, err := sharded.Get(context.Background(), sk{name: fmt.Sprintf("key %d", i)}); err != nil {
+				t.Errorf("could not get item %d with a positive zero: %s", i, err)
+			}
+		}
+	})
+
+	t.Run("reject an eviction policy shared by the shards", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("could not panic on a shared eviction policy")
+			}
+		}()
+		NewShardedInMemory[string, string](8, time.Minute, 100, EvictionPolicyOption[string, string](NewLRUPolicy[string]()))
+	})
+
+	t.Run("concurrent set, get, and delete", func(t *testing.T) {
+		sharded := newShardedInMemHelper(t, 100, EvictionPolicyFuncOption[string, string](func() EvictionPolicy[string] {
+			return NewLRUPolicy[string]()
+		}))
+
+		const c = 100
+		wg := sync.WaitGroup{}
+		wg.Add(c)
+
+		for i := 0; i < c; i++ {
+			go func(i int) {
+				defer wg.Done()
+				k := fmt.Sprintf("key %d", i)
+				_, _ = sharded.Get(context.Background(), k)
+				_ = sharded.Set(context.Background(), k, k, time.Second)
+				_ = sharded.Delete(context.Background(), k)
+			}(i)
+		}
+
+		wg.Wait()
+	})
+}
+
+func newShardedInMemHelper(t *testing.T, cap int, opts ...InMemOption[string, string]) *ShardedInMem[string, string] {
+	t.Helper()
+	sharded := NewShardedInMemory[string, string](8, time.Minute, cap, opts...)
+	t.Cleanup(func() {
+		if err := sharded.Close(); err != nil {
+			t.Errorf("could not close sharded inmem: %s", err)
+		}
+	})
+	return sharded
+}
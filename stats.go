@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"errors"
+
+	"github.com/damianopetrungaro/go-cache/internal/stats"
+)
+
+// Stats represents the statistics of a cache since its creation
+type Stats struct {
+	// Hits are the reads which found the item
+	Hits uint64
+	// Misses are the reads which could not find the item
+	Misses uint64
+	// Expired are the reads which found the item expired, before it got removed
+	Expired uint64
+	// Errors are the reads which failed for any other reason, such as a network or a decoding error
+	Errors uint64
+	// Sets are the items stored
+	Sets uint64
+	// Deletes are the items requested to be removed, either present or not
+	Deletes uint64
+	// Evictions are the items which left the cache, by reason. It is nil when the cache cannot tell them
+	Evictions map[EvictionReason]uint64
+	// Size is the number of items in the cache, -1 when the cache cannot tell it
+	Size int
+	// LoadErrors are the loads failed by a Loader
+	LoadErrors uint64
+	// Levels are the statistics of every level of a MultiLevel
+	Levels []Stats
+}
+
+// HitRatio returns the ratio of reads which found the item, zero when there were no reads
+func (s Stats) HitRatio() float64 {
+	reads := s.Hits + s.Misses + s.Expired + s.Errors
+	if reads == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(reads)
+}
+
+// StatsReporter represents a cache able to report its statistics
+// The counters are updated atomically, so reporting them does not slow the cache down
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// newStats returns the Stats of the given counters, without the evictions
+func newStats(c *stats.Counters, size int) Stats {
+	return Stats{
+		Hits:       c.Hits.Load(),
+		Misses:     c.Misses.Load(),
+		Expired:    c.Expired.Load(),
+		Errors:     c.Errors.Load(),
+		Sets:       c.Sets.Load(),
+		Deletes:    c.Deletes.Load(),
+		Size:       size,
+		LoadErrors: c.LoadErrors.Load(),
+	}
+}
+
+// evictions returns the evictions of the given counters for every reason
+func evictions(c *stats.Counters) map[EvictionReason]uint64 {
+	evictions := make(map[EvictionReason]uint64, EvictionReasonReplaced)
+	for r := EvictionReasonExpired; r <= EvictionReasonReplaced; r++ {
+		evictions[r] = c.Evictions[r].Load()
+	}
+	return evictions
+}
+
+// add sums the given Stats to the ones of s
+func (s *Stats) add(o Stats) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Expired += o.Expired
+	s.Errors += o.Errors
+	s.Sets += o.Sets
+	s.Deletes += o.Deletes
+	s.Size += o.Size
+	s.LoadErrors += o.LoadErrors
+	for r, n := range o.Evictions {
+		if s.Evictions == nil {
+			s.Evictions = map[EvictionReason]uint64{}
+		}
+		s.Evictions[r] += n
+	}
+}
+
+// recordGet counts the outcome of a read
+func recordGet(c *stats.Counters, err error) {
+	switch {
+	case err == nil:
+		c.Hits.Inc()
+	case errors.Is(err, ErrExpired):
+		c.Expired.Inc()
+	case errors.Is(err, ErrNotFound):
+		c.Misses.Inc()
+	default:
+		c.Errors.Inc()
+	}
+}
+
+// recordGetMany counts the outcome of the reads of many items
+func recordGetMany[K comparable, V any](c *stats.Counters, vals map[K]V, errs map[K]error) {
+	c.Hits.Add(len(vals))
+	for _, err := range errs {
+		recordGet(c, err)
+	}
+}
+
+// statsOf returns the Stats of the cache when it is a StatsReporter, otherwise empty ones with an unknown size
+func statsOf(c any) Stats {
+	if r, ok := c.(StatsReporter); ok {
+		return r.Stats()
+	}
+	return Stats{Size: -1}
+}
@@ -0,0 +1,160 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/damianopetrungaro/go-cache"
+	"github.com/damianopetrungaro/go-cache/cachetest"
+)
+
+func TestStats(t *testing.T) {
+	t.Run("inmem", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		inmem := newInMemHelper(t, LRUOption[string, string](), ClockOption[string, string](clock))
+
+		_ = inmem.Set(context.Background(), "one", "1", time.Millisecond)
+		_ = inmem.Set(context.Background(), "two", "2", NoExpiration)
+		_ = inmem.Set(context.Background(), "two", "2", NoExpiration)
+		_ = inmem.Set(context.Background(), "three", "3", NoExpiration)
+		_ = inmem.Set(context.Background(), "four", "4", NoExpiration)
+		_ = inmem.Delete(context.Background(), "four")
+		_ = inmem.Delete(context.Background(), "five")
+
+		clock.Advance(2 * time.Millisecond)
+		_, _ = inmem.Get(context.Background(), "two")
+		_, _ = inmem.Get(context.Background(), "three")
+		_, _ = inmem.Get(context.Background(), "four")
+		_, _ = inmem.GetMany(context.Background(), []string{"two", "five"})
+
+		got := inmem.Stats()
+		want := Stats{
+			Hits:    3,
+			Misses:  2,
+			Sets:    5,
+			Deletes: 2,
+			Evictions: map[EvictionReason]uint64{
+				EvictionReasonExpired:  0,
+				EvictionReasonCapacity: 1,
+				EvictionReasonDeleted:  1,
+				EvictionReasonReplaced: 1,
+			},
+			Size: 2,
+		}
+		if !equalStats(got, want) {
+			t.Errorf("could not match stats, got: %+v. want: %+v", got, want)
+		}
+
+		if ratio := got.HitRatio(); ratio != 0.6 {
+			t.Errorf("could not match hit ratio, got: %f", ratio)
+		}
+	})
+
+	t.Run("inmem expired on read", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		inmem := newInMemHelper(t, ClockOption[string, string](clock))
+
+		_ = inmem.Set(context.Background(), "one", "1", time.Millisecond)
+		clock.Advance(2 * time.Millisecond)
+		_, _ = inmem.Get(context.Background(), "one")
+
+		if got := inmem.Stats(); got.Expired != 1 || got.Misses != 0 {
+			t.Errorf("could not match expired reads, got: %+v", got)
+		}
+	})
+
+	t.Run("sharded inmem", func(t *testing.T) {
+		sharded := NewShardedInMemory[string, string](4, time.Minute, 100)
+		t.Cleanup(func() { _ = sharded.Close() })
+
+		for _, k := range []string{"one", "two", "three", "four"} {
+			_ = sharded.Set(context.Background(), k, "value", NoExpiration)
+			_, _ = sharded.Get(context.Background(), k)
+		}
+		_, _ = sharded.Get(context.Background(), "five")
+
+		got := sharded.Stats()
+		if got.Hits != 4 || got.Misses != 1 || got.Sets != 4 || got.Size != 4 {
+			t.Errorf("could not match stats, got: %+v", got)
+		}
+	})
+
+	t.Run("loader", func(t *testing.T) {
+		loader := NewLoader[string, string](newInMemHelper(t))
+		load := func(context.Context) (string, time.Duration, error) {
+			return "", 0, errors.New("load failure")
+		}
+
+		_, _ = loader.GetOrLoad(context.Background(), "one", load)
+		_, _ = loader.GetOrLoad(context.Background(), "two", load)
+
+		if got := loader.Stats(); got.LoadErrors != 2 || got.Misses != 2 || got.Size != 0 {
+			t.Errorf("could not match stats, got: %+v", got)
+		}
+
+		if got := NewLoader[string, string](singleCache{}).Stats(); got.Size != -1 {
+			t.Errorf("could not match unknown size, got: %d", got.Size)
+		}
+	})
+
+	t.Run("multi level", func(t *testing.T) {
+		clock := cachetest.NewClock(time.Now())
+		levels := newLevels(t, clock, 2)
+		multiLvl := newMultiLevelHelper(t, levels, MultiLevelClockOption[string, string](clock))
+
+		_ = multiLvl.Set(context.Background(), "one", "1", NoExpiration)
+		_ = levels[1].Cache.Set(context.Background(), "two", "2", NoExpiration)
+		_ = multiLvl.Delete(context.Background(), "three")
+
+		_, _ = multiLvl.Get(context.Background(), "one")
+		_, _ = multiLvl.Get(context.Background(), "one")
+		_, _ = multiLvl.Get(context.Background(), "two")
+		_, _ = multiLvl.GetMany(context.Background(), []string{"one", "three"})
+
+		got := multiLvl.Stats()
+		if got.Hits != 4 || got.Misses != 1 || got.Sets != 1 || got.Deletes != 1 || got.Size != 2 {
+			t.Errorf("could not match stats, got: %+v", got)
+		}
+
+		if len(got.Levels) != 2 {
+			t.Fatalf("could not match levels, got: %d", len(got.Levels))
+		}
+
+		if l1 := got.Levels[0]; l1.Hits != 3 || l1.Misses != 2 || l1.Sets != 1 || l1.Deletes != 1 || l1.Size != 1 {
+			t.Errorf("could not match first level stats, got: %+v", l1)
+		}
+
+		if l2 := got.Levels[1]; l2.Hits != 1 || l2.Misses != 1 || l2.Sets != 1 || l2.Deletes != 1 || l2.Size != 2 {
+			t.Errorf("could not match second level stats, got: %+v", l2)
+		}
+
+		if got.Levels[0].Evictions[EvictionReasonDeleted] != 0 || got.Levels[1].Evictions == nil {
+			t.Errorf("could not match level evictions, got: %+v", got.Levels)
+		}
+	})
+}
+
+func equalStats(got, want Stats) bool {
+	if len(got.Evictions) != len(want.Evictions) {
+		return false
+	}
+
+	for r, n := range want.Evictions {
+		if got.Evictions[r] != n {
+			return false
+		}
+	}
+
+	got.Evictions, want.Evictions = nil, nil
+	got.Levels, want.Levels = nil, nil
+	return got.Hits == want.Hits &&
+		got.Misses == want.Misses &&
+		got.Expired == want.Expired &&
+		got.Errors == want.Errors &&
+		got.Sets == want.Sets &&
+		got.Deletes == want.Deletes &&
+		got.Size == want.Size &&
+		got.LoadErrors == want.LoadErrors
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrorClass represents the class of an error returned by a Cache, by the sentinel it wraps
+type ErrorClass int
+
+// List of classes of the errors returned by a Cache
+const (
+	ErrorClassNone ErrorClass = iota
+	ErrorClassMiss
+	ErrorClassNotGet
+	ErrorClassNotSet
+	ErrorClassNotDelete
+	ErrorClassUnknown
+)
+
+// ClassifyError returns the class of the error.
+// ErrNotFound and ErrExpired are misses rather than failures, even though they wrap ErrNotGet
+func ClassifyError(err error) ErrorClass {
+	switch {
+	case err == nil:
+		return ErrorClassNone
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrExpired):
+		return ErrorClassMiss
+	case errors.Is(err, ErrNotGet):
+		return ErrorClassNotGet
+	case errors.Is(err, ErrNotSet):
+		return ErrorClassNotSet
+	case errors.Is(err, ErrNotDelete):
+		return ErrorClassNotDelete
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// String returns the label of the class, as used by the metrics
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassNone:
+		return "none"
+	case ErrorClassMiss:
+		return "miss"
+	case ErrorClassNotGet:
+		return "not_get"
+	case ErrorClassNotSet:
+		return "not_set"
+	case ErrorClassNotDelete:
+		return "not_delete"
+	default:
+		return "unknown"
+	}
+}
+
+// RedactKey returns the first 8 bytes of the SHA-256 of the key, hex encoded, so that keys are not leaked to logs and telemetry.
+// It can be reversed by guessing keys with few possible values, such as emails, in which case a keyed hash should be used
+func RedactKey[K comparable](k K) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(k)))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,55 @@
+package cache_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/damianopetrungaro/go-cache"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := map[string]struct {
+		err   error
+		class ErrorClass
+		label string
+	}{
+		"nil":        {err: nil, class: ErrorClassNone, label: "none"},
+		"not found":  {err: ErrNotFound, class: ErrorClassMiss, label: "miss"},
+		"expired":    {err: fmt.Errorf("%w: %s", ErrExpired, "key"), class: ErrorClassMiss, label: "miss"},
+		"not get":    {err: ErrNotGet, class: ErrorClassNotGet, label: "not_get"},
+		"not load":   {err: ErrNotLoad, class: ErrorClassNotGet, label: "not_get"},
+		"not set":    {err: ErrNotSet, class: ErrorClassNotSet, label: "not_set"},
+		"too large":  {err: ErrTooLarge, class: ErrorClassNotSet, label: "not_set"},
+		"not delete": {err: ErrNotDelete, class: ErrorClassNotDelete, label: "not_delete"},
+		"unknown":    {err: errors.New("boom"), class: ErrorClassUnknown, label: "unknown"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			class := ClassifyError(test.err)
+			if class != test.class {
+				t.Errorf("could not match class, got: %s. want: %s", class, test.class)
+			}
+
+			if class.String() != test.label {
+				t.Errorf("could not match label, got: %s. want: %s", class, test.label)
+			}
+		})
+	}
+}
+
+func TestRedactKey(t *testing.T) {
+	got := RedactKey("key")
+	if len(got) != 16 || got == "key" {
+		t.Errorf("could not redact key, got: %s", got)
+	}
+
+	if RedactKey("key") != got {
+		t.Error("could not redact key deterministically")
+	}
+
+	if RedactKey("other") == got {
+		t.Error("could not redact keys to different values")
+	}
+}
@@ -0,0 +1,275 @@
+package cache
+
+const (
+	windowSegment uint8 = iota + 1
+	probationSegment
+	protectedSegment
+)
+
+// tinyLFU is a W-TinyLFU policy.
+// New items enter a small window LRU, when the window overflows its least recent item
+// becomes a candidate for the main segmented LRU,
+// and a count-min sketch decides whether the candidate is worth more than the main victim.
+// The segments are bounded by both the number and the cost of their items, so that the window stays small
+// whether the cache is bounded by its capacity or by its max cost
+type tinyLFU[K comparable, V any] struct {
+	window           list[K, V]
+	probation        list[K, V]
+	protected        list[K, V]
+	windowCap        int
+	protectedCap     int
+	windowMaxCost    int64
+	protectedMaxCost int64
+	windowCost       int64
+	protectedCost    int64
+	sketch           *sketch
+}
+
+func newTinyLFU[K comparable, V any](cap int, maxCost int64) *tinyLFU[K, V] {
+	t := &tinyLFU[K, V]{}
+	t.windowCap, t.protectedCap = segmentCaps(cap)
+	t.windowMaxCost, t.protectedMaxCost = segmentCaps(maxCost)
+
+	// the cache holds at most maxCost items costing one
+	items := cap
+	if maxCost < int64(cap) {
+		items = int(maxCost)
+	}
+	t.sketch = newSketch(items)
+	return t
+}
+
+// segmentCaps splits a capacity between the window, taking 1% of it, and the protected segment, taking 80% of the rest.
+// It is computed without overflowing huge capacities such as math.MaxInt
+func segmentCaps[N int | int64](cap N) (N, N) {
+	window := cap / 100
+	if window < 1 {
+		window = 1
+	}
+
+	main := cap - window
+	return window, main - main/5
+}
+
+func (t *tinyLFU[K, V]) access(k K, it *item[K, V]) {
+	if it == nil {
+		t.sketch.increment(hashKey(k))
+		return
+	}
+
+	t.sketch.increment(it.hash)
+	switch it.segment {
+	case windowSegment:
+		t.window.moveToFront(it)
+	case probationSegment:
+		t.probation.remove(it)
+		t.protected.pushFront(it)
+		t.protectedCost += it.cost
+		it.segment = protectedSegment
+		for t.protected.len > 0 && (t.protected.len > t.protectedCap || t.protectedCost > t.protectedMaxCost) {
+			demoted := t.protected.back()
+			t.protected.remove(demoted)
+			t.protectedCost -= demoted.cost
+			t.probation.pushFront(demoted)
+			demoted.segment = probationSegment
+		}
+	case protectedSegment:
+		t.protected.moveToFront(it)
+	}
+}
+
+func (t *tinyLFU[K, V]) insert(it *item[K, V]) {
+	it.hash = hashKey(it.key)
+	t.sketch.increment(it.hash)
+
+	t.window.pushFront(it)
+	t.windowCost += it.cost
+	it.segment = windowSegment
+	for t.window.len > 1 && (t.window.len > t.windowCap || t.windowCost > t.windowMaxCost) {
+		candidate := t.window.back()
+		t.window.remove(candidate)
+		t.windowCost -= candidate.cost
+		t.probation.pushFront(candidate)
+		candidate.segment = probationSegment
+	}
+}
+
+func (t *tinyLFU[K, V]) remove(it *item[K, V]) {
+	switch it.segment {
+	case windowSegment:
+		t.window.remove(it)
+		t.windowCost -= it.cost
+	case probationSegment:
+		t.probation.remove(it)
+	case protectedSegment:
+		t.protected.remove(it)
+		t.protectedCost -= it.cost
+	}
+}
+
+func (t *tinyLFU[K, V]) resize(it *item[K, V], cost int64) {
+	switch it.segment {
+	case windowSegment:
+		t.windowCost += cost - it.cost
+	case protectedSegment:
+		t.protectedCost += cost - it.cost
+	}
+}
+
+// victim compares the latest candidate admitted from the window with the least recent item of the main LRU,
+// the least frequently used one between the two gets evicted
+func (t *tinyLFU[K, V]) victim() *item[K, V] {
+	candidate := t.probation.head
+	victim := t.probation.back()
+	if victim == candidate {
+		victim = t.protected.back()
+	}
+
+	switch {
+	case candidate == nil:
+		return t.window.back()
+	case victim == nil:
+		return candidate
+	case t.sketch.estimate(candidate.hash) > t.sketch.estimate(victim.hash):
+		return victim
+	default:
+		return candidate
+	}
+}
+
+const (
+	sketchDepth   = 4
+	sketchMaxFreq = 15
+	// sketchMaxWidth bounds the rows to 4MiB each, so that huge capacities such as math.MaxInt
+	// neither overflow the width and the sample size nor allocate huge rows
+	sketchMaxWidth = 1 << 22
+	// doorHashes is the number of bits set in the doorkeeper for every key
+	doorHashes = 2
+)
+
+// sketch is a count-min sketch estimating the access frequency of the keys.
+// A doorkeeper bloom filter records the first access of the keys, so that the keys accessed once,
+// such as the ones of a scan, never reach the counters and do not inflate the estimates of the others.
+// Counters saturate at sketchMaxFreq and all of them get halved once the sample size is reached,
+// so that the old popularity of a key fades over time
+type sketch struct {
+	rows       [sketchDepth][]uint8
+	door       []uint64
+	mask       uint64
+	doorMask   uint64
+	additions  int
+	sampleSize int
+}
+
+func newSketch(cap int) *sketch {
+	switch {
+	case cap < 16:
+		cap = 16
+	case cap > sketchMaxWidth/16:
+		cap = sketchMaxWidth / 16
+	}
+
+	// each row is much wider than the capacity, so that a key rarely collides with frequently accessed ones in every row
+	width := 64
+	for width < 16*cap {
+		width *= 2
+	}
+
+	// the doorkeeper holds the keys accessed between two resets, which are about 5 times the capacity
+	doorWidth := 64
+	for doorWidth < 32*cap {
+		doorWidth *= 2
+	}
+
+	s := &sketch{
+		door:       make([]uint64, doorWidth/64),
+		mask:       uint64(width - 1),
+		doorMask:   uint64(doorWidth - 1),
+		sampleSize: 10 * cap,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *sketch) increment(h uint64) {
+	added := false
+	switch s.admitted(h) {
+	case false:
+		s.admit(h)
+		added = true
+	default:
+		for i := range s.rows {
+			idx := s.index(h, i)
+			if s.rows[i][idx] < sketchMaxFreq {
+				s.rows[i][idx]++
+				added = true
+			}
+		}
+	}
+
+	if !added {
+		return
+	}
+
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// estimate returns the frequency of the key, counting the first access recorded by the doorkeeper
+func (s *sketch) estimate(h uint64) uint8 {
+	min := uint8(sketchMaxFreq)
+	for i := range s.rows {
+		if c := s.rows[i][s.index(h, i)]; c < min {
+			min = c
+		}
+	}
+
+	if min < sketchMaxFreq && s.admitted(h) {
+		min++
+	}
+	return min
+}
+
+// reset ages the sketch halving every counter and clearing the doorkeeper
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	for i := range s.door {
+		s.door[i] = 0
+	}
+	s.additions /= 2
+}
+
+func (s *sketch) index(h uint64, row int) uint64 {
+	return mix(h+uint64(row)*0x9e3779b97f4a7c15) & s.mask
+}
+
+// admitted reports whether the doorkeeper recorded an access to the key
+func (s *sketch) admitted(h uint64) bool {
+	for i := 0; i < doorHashes; i++ {
+		bit := s.doorIndex(h, i)
+		if s.door[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// admit records an access to the key in the doorkeeper
+func (s *sketch) admit(h uint64) {
+	for i := 0; i < doorHashes; i++ {
+		bit := s.doorIndex(h, i)
+		s.door[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (s *sketch) doorIndex(h uint64, i int) uint64 {
+	return mix(h+uint64(sketchDepth+i)*0x9e3779b97f4a7c15) & s.doorMask
+}
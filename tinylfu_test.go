@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSketch(t *testing.T) {
+	t.Run("estimate incremented keys", func(t *testing.T) {
+		s := newSketch(100)
+		hot, cold := hashKey("hot"), hashKey("cold")
+
+		for i := 0; i < 5; i++ {
+			s.increment(hot)
+		}
+		s.increment(cold)
+
+		if got := s.estimate(hot); got < 5 {
+			t.Errorf("could not match hot estimate, got: %d", got)
+		}
+
+		if got := s.estimate(cold); got >= s.estimate(hot) {
+			t.Errorf("could not match cold estimate, got: %d", got)
+		}
+	})
+
+	t.Run("saturate counters", func(t *testing.T) {
+		s := newSketch(100)
+		k := hashKey("key")
+
+		for i := 0; i < 100; i++ {
+			s.increment(k)
+		}
+
+		if got := s.estimate(k); got != sketchMaxFreq {
+			t.Errorf("could not match saturated estimate, got: %d", got)
+		}
+	})
+
+	t.Run("age counters on sample size", func(t *testing.T) {
+		s := newSketch(16)
+		k := hashKey("key")
+		// the first access is recorded by the doorkeeper, which gets cleared, the other 8 by the counters
+		for i := 0; i < 9; i++ {
+			s.increment(k)
+		}
+
+		s.additions = s.sampleSize - 1
+		s.increment(hashKey("another key"))
+
+		if got := s.estimate(k); got != 4 {
+			t.Errorf("could not match aged estimate, got: %d", got)
+		}
+
+		if s.additions != s.sampleSize/2 {
+			t.Errorf("could not match aged additions, got: %d", s.additions)
+		}
+	})
+
+	t.Run("keep keys accessed once out of the counters", func(t *testing.T) {
+		s := newSketch(100)
+		k := hashKey("key")
+		s.increment(k)
+
+		if got := s.estimate(k); got != 1 {
+			t.Errorf("could not match estimate, got: %d", got)
+		}
+
+		for i := range s.rows {
+			if got := s.rows[i][s.index(k, i)]; got != 0 {
+				t.Errorf("could not keep key out of the counters, got: %d", got)
+			}
+		}
+	})
+
+	t.Run("bound huge capacities", func(t *testing.T) {
+		s := newSketch(math.MaxInt)
+		if len(s.rows[0]) != sketchMaxWidth {
+			t.Errorf("could not match width, got: %d", len(s.rows[0]))
+		}
+
+		if s.sampleSize <= 0 {
+			t.Fatalf("could not match sample size, got: %d", s.sampleSize)
+		}
+
+		k := hashKey("key")
+		for i := 0; i < 8; i++ {
+			s.increment(k)
+		}
+
+		if got := s.estimate(k); got != 8 {
+			t.Errorf("could not match estimate, got: %d", got)
+		}
+	})
+}
+
+func TestTinyLFU(t *testing.T) {
+	t.Run("size segments of huge capacities", func(t *testing.T) {
+		p := newTinyLFU[string, string](math.MaxInt, math.MaxInt64)
+		if p.windowCap <= 0 || p.protectedCap <= 0 || p.protectedCap > math.MaxInt-p.windowCap {
+			t.Errorf("could not match segments, got: %d %d", p.windowCap, p.protectedCap)
+		}
+	})
+}